@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"net/url"
+)
+
+const (
+	slashCmdArgumentsField = "arguments"
+
+	ArgumentsKey = "arguments"
+)
+
+// ArgumentsHandler parses the arguments of a slash command against the
+// positional arguments and flags its function declares in the registry.
+// It should come before the RegistryHandler and ProxyHandler.
+type ArgumentsHandler struct {
+	registry registryInterface
+}
+
+// NewArgumentsHandler is a factory method for creating the arguments
+// handler with a loaded command registry.
+func NewArgumentsHandler(registry registryInterface) ArgumentsHandler {
+	return ArgumentsHandler{
+		registry: registry,
+	}
+}
+
+// Before method that parses the arguments of the slash command's function,
+// writes them into the context as router.Arguments, and adds them to the
+// request body as a JSON encoded 'arguments' field. The request is stopped
+// with the error recorded on the task if the arguments do not match, and
+// the user is shown the function's usage. Functions that declare no
+// arguments, and commands that can not be found, are left alone.
+func (a *ArgumentsHandler) Before(context *router.ContextMap, task *router.TaskMap) bool {
+	cmd, err := slashCommand(context, task)
+	if err != nil {
+		return false
+	}
+
+	registry := registrySnapshot(context, a.registry).(registryInterface)
+	funcRec, err := registry.GetFunctionRecord(cmd)
+	if err != nil || !funcRec.HasArguments() {
+		return false
+	}
+
+	args, err := funcRec.ParseArguments()
+	if err != nil {
+		recordErr(task, err)
+		return true
+	}
+	(*context)[ArgumentsKey] = args
+
+	form, err := slashCommandForm(context, task)
+	if err != nil {
+		recordErr(task, err)
+		return true
+	}
+	body, err := withArguments(form, args)
+	if err != nil {
+		recordErr(task, err)
+		return true
+	}
+	task.SetBody(body)
+
+	return false
+}
+
+// Execute method that does nothing.
+func (a *ArgumentsHandler) Execute(context *router.ContextMap, task *router.TaskMap) {}
+
+// After method that does nothing.
+func (a *ArgumentsHandler) After(context *router.ContextMap, task *router.TaskMap) {}
+
+// withArguments adds the JSON encoded arguments to the slash command's
+// form, and returns it encoded as the new body.
+func withArguments(form url.Values, args router.Arguments) (string, error) {
+	encodedArgs, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	form.Set(slashCmdArgumentsField, string(encodedArgs))
+
+	return form.Encode(), nil
+}
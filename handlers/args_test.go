@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+const testArgsRegJson = `{
+           "/deploy": {
+               "functions" : {
+                   "ship" : {
+                       "usage" : "/deploy ship <service> [--wait <duration>]",
+                       "arguments" : [{"name" : "service", "required" : true}],
+                       "flags" : [{"name" : "wait", "type" : "duration"}]
+                   },
+                   "rollback" : {
+                       "usage" : "/deploy rollback <service>"
+                   }
+               }
+           }
+        }`
+
+func newTestArgumentsHandler(t *testing.T) ArgumentsHandler {
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(testArgsRegJson))
+	assert.Nil(t, err)
+
+	return NewArgumentsHandler(cmdReg)
+}
+
+func TestArgumentsHandler(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fdeploy&text=ship+api+--wait%3D2m&user_id=U1"}
+	testHandler := newTestArgumentsHandler(t)
+
+	assert.False(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testCtx[ArgumentsKey], router.Arguments{"service": "api", "wait": 2 * time.Minute})
+
+	form, err := url.ParseQuery(testTask.Body())
+	assert.Nil(t, err)
+	assert.Equal(t, form.Get("text"), "ship api --wait=2m")
+	assert.Equal(t, form.Get("user_id"), "U1")
+	assert.JSONEq(t, form.Get("arguments"), `{"service": "api", "wait": "2m0s"}`)
+}
+
+func TestArgumentsHandlerInvalid(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fdeploy&text=ship+--wait+soon"}
+	testHandler := newTestArgumentsHandler(t)
+	errHandler := NewErrorHandler()
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	errHandler.After(&testCtx, &testTask)
+	statusCode, _ := testTask.StatusCode()
+	assert.Equal(t, statusCode, http.StatusOK)
+	assert.Equal(t, testTask.Body(), string(ephemeralMessage("'wait' must be a duration such as 90s or 5m, not 'soon'.\nUsage: `/deploy ship &lt;service&gt; [--wait &lt;duration&gt;]`")))
+}
+
+func TestArgumentsHandlerPassesThrough(t *testing.T) {
+	testHandler := newTestArgumentsHandler(t)
+	for _, body := range []string{"command=%2Fdeploy&text=rollback+api+--now", "command=%2Fdeploy&text=launch", "text=ship"} {
+		testCtx := make(router.ContextMap)
+		testTask := router.TaskMap{TaskBody: body}
+
+		assert.False(t, testHandler.Before(&testCtx, &testTask), body)
+		assert.Equal(t, testTask.Body(), body)
+		assert.Nil(t, testTask.Err())
+		_, hasArgs := testCtx[ArgumentsKey]
+		assert.False(t, hasArgs)
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	slashCmdResponseUrlField = "response_url"
+	requestAsync             = "request-async"
+	requestAck               = "request-acknowledgement"
+
+	defaultAckMsg           = "Working on it..."
+	defaultResponseHost     = "hooks.slack.com"
+	defaultResponseRetries  = 3
+	defaultResponseBackoff  = 500 * time.Millisecond
+	defaultResponseTimeout  = 10 * time.Second
+	defaultCallTimeout      = time.Minute
+	errResponseUrlStatusFmt = "Unexpected status posting to response url. Status: %s"
+	logAsyncFailedMsg       = "Asynchronous function call failed."
+	logDeliveryFailedMsg    = "Could not post the result to the response url."
+	logDeliveryRetryFmt     = "Retrying post to response url in %s. Attempt: %d, Error: %v\n"
+	logResponseUrlRefused   = "Response url is not an allowed https url; calling the function synchronously. Url: '%s'\n"
+)
+
+// Dispatcher runs work in the background, after the acknowledgement of an
+// asynchronous function has been returned to Slack.
+type Dispatcher func(work func())
+
+// GoDispatcher runs work in a new goroutine. It suits long running
+// servers only: where the process is frozen once a response is returned,
+// as on AWS Lambda, the goroutine may never finish, and a dispatcher that
+// keeps the process running until the work is done must be used instead.
+func GoDispatcher(work func()) {
+	go work()
+}
+
+// AsyncOptions configures how the ProxyHandler calls asynchronous
+// functions and delivers their results to Slack.
+type AsyncOptions struct {
+	// Dispatch runs the call to the function and the delivery of its
+	// result. There is no default, as no dispatcher suits every platform;
+	// while it is nil, asynchronous functions are called synchronously.
+	Dispatch Dispatcher
+	// Client posts results to response urls. It is kept apart from the
+	// client that calls functions, and defaults to an http.Client whose
+	// timeout is Timeout.
+	Client httpClientInterface
+	// ResponseHosts are the hosts results may be posted to. Response urls
+	// must also be https. It defaults to hooks.slack.com.
+	ResponseHosts []string
+	// Retries is the number of attempts made to post the result after the
+	// first fails with a network error, a 5xx status or a 429 status.
+	Retries int
+	// Backoff is the wait before the first retry. It doubles on every
+	// retry after that.
+	Backoff time.Duration
+	// Timeout bounds each attempt to post the result.
+	Timeout time.Duration
+	// CallTimeout bounds the call to a function that has no timeout of
+	// its own in the registry, as the dispatched call outlives the
+	// request and would otherwise wait on the function forever.
+	CallTimeout time.Duration
+}
+
+// DefaultAsyncOptions returns the options used by NewProxyHandler.
+func DefaultAsyncOptions() AsyncOptions {
+	return AsyncOptions{
+		ResponseHosts: []string{defaultResponseHost},
+		Retries:       defaultResponseRetries,
+		Backoff:       defaultResponseBackoff,
+		Timeout:       defaultResponseTimeout,
+		CallTimeout:   defaultCallTimeout,
+	}
+}
+
+// canDispatch reports whether an asynchronous function can be dispatched
+// with its result posted to the response url. It needs a dispatcher, and
+// an https response url on one of the allowed hosts.
+func (p *ProxyHandler) canDispatch(responseUrl string) bool {
+	if p.async.Dispatch == nil || responseUrl == "" {
+		return false
+	}
+
+	parsedUrl, err := url.Parse(responseUrl)
+	if err == nil && parsedUrl.Scheme == "https" {
+		for _, host := range p.async.ResponseHosts {
+			if strings.EqualFold(parsedUrl.Hostname(), host) {
+				return true
+			}
+		}
+	}
+	log.Printf(logResponseUrlRefused, responseUrl)
+
+	return false
+}
+
+// executeAsync acknowledges the slash command in the task, and dispatches
+// the call to the function, whose result is posted to the response url.
+// The call is detached from the request's context.Context, which ends
+// once the acknowledgement is returned, and is instead bounded by the
+// function's timeout or the CallTimeout.
+func (p *ProxyHandler) executeAsync(ctxMap *router.ContextMap, task *router.TaskMap, preq *proxyRequest, responseUrl string) {
+	detached := make(router.ContextMap, len(*ctxMap)+1)
+	for key, val := range *ctxMap {
+		if key != router.RequestContextKey {
+			detached[key] = val
+		}
+	}
+	if detached.Duration(requestTimeout) <= 0 {
+		detached[requestTimeout] = p.async.CallTimeout
+	}
+
+	p.async.Dispatch(func() {
+		p.deliver(responseUrl, p.asyncResult(&detached, preq))
+	})
+
+	ack, _ := ctxMap.String(requestAck)
+	if ack == "" {
+		ack = defaultAckMsg
+	}
+	task.SetBody(ack)
+}
+
+// asyncResult calls the function and returns the message to post to the
+// response url. Responses that are JSON objects are taken to be Slack
+// messages and posted as they are, and anything else is posted as the
+// text of an ephemeral message. If the call fails, or the status policy
+// treats the response's status as an error, the message says so.
+func (p *ProxyHandler) asyncResult(ctxMap *router.ContextMap, preq *proxyRequest) []byte {
+	resp, cancel, err := p.send(ctxMap, preq)
+	defer cancel()
+
+	var respBody []byte
+	if err == nil {
+		respBody, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err == nil && p.statusPolicy(resp.StatusCode) {
+		err = UpstreamStatusError{
+			Url:        preq.url,
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+		}
+	}
+	if err != nil {
+		log.Printf(logMsg, logAsyncFailedMsg, err.Error())
+		msg := errRespMsg(err)
+		if msg == "" {
+			msg = kindResponses[ErrorKindOf(err)].msg
+		}
+		return ephemeralMessage(msg)
+	}
+
+	trimmed := bytes.TrimSpace(respBody)
+	if bytes.HasPrefix(trimmed, []byte("{")) && json.Valid(trimmed) {
+		return trimmed
+	}
+
+	return ephemeralMessage(string(respBody))
+}
+
+// deliver posts the message to the response url, retrying failures that
+// may be temporary.
+func (p *ProxyHandler) deliver(responseUrl string, msg []byte) {
+	var retry bool
+	var err error
+	for attempt := 0; ; attempt++ {
+		retry, err = p.postResponse(responseUrl, msg)
+		if err == nil || !retry || attempt >= p.async.Retries {
+			break
+		}
+
+		wait := p.async.Backoff << uint(attempt)
+		log.Printf(logDeliveryRetryFmt, wait, attempt+1, err)
+		p.sleep(wait)
+	}
+
+	if err != nil {
+		log.Printf(logMsg, logDeliveryFailedMsg, err.Error())
+	}
+}
+
+// postResponse posts the message to the response url once, and reports
+// whether a failure is worth retrying.
+func (p *ProxyHandler) postResponse(responseUrl string, msg []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.async.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseUrl, bytes.NewReader(msg))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", jsonContentType)
+
+	resp, err := p.async.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	if resp.Body != nil {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf(errResponseUrlStatusFmt, resp.Status)
+}
+
+func ephemeralMessage(text string) []byte {
+	msg, _ := json.Marshal(slackMessage{
+		ResponseType: ephemeralRespType,
+		Text:         text,
+	})
+
+	return msg
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testResponseUrl  = "https://hooks.slack.com/commands/T1/123/abc"
+	testAsyncReqBody = "command=%2Fops&text=backup&response_url=https%3A%2F%2Fhooks.slack.com%2Fcommands%2FT1%2F123%2Fabc"
+)
+
+// newTestAsyncProxyHandler creates a proxy handler whose dispatched work
+// is kept for the test to run, and whose sleeps are recorded. The client
+// both calls functions and posts to response urls.
+func newTestAsyncProxyHandler(client httpClientInterface) (ProxyHandler, *[]func(), *[]time.Duration) {
+	var work []func()
+	var sleeps []time.Duration
+	testHandler := NewProxyHandlerWithAsyncOptions(client, AsyncOptions{
+		Dispatch: func(w func()) { work = append(work, w) },
+		Client:   client,
+		Retries:  2,
+		Backoff:  time.Second,
+	})
+	testHandler.sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	return testHandler, &work, &sleeps
+}
+
+func newTestAsyncContext() router.ContextMap {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	return router.ContextMap{
+		router.RequestContextKey: ctx,
+		requestUrl:               testProxyUrl,
+		requestAsync:             true,
+		requestAck:               "Backing up...",
+	}
+}
+
+func isFunctionCall(req *http.Request) bool {
+	return req.Method == http.MethodPost && req.URL.String() == testProxyUrl
+}
+
+func isResponseUrl(req *http.Request) bool {
+	return req.URL.String() == testResponseUrl
+}
+
+func isResponseUrlPost(body string) func(req *http.Request) bool {
+	return func(req *http.Request) bool {
+		reqBody, _ := ioutil.ReadAll(req.Body)
+		return req.Method == http.MethodPost &&
+			req.URL.String() == testResponseUrl &&
+			req.Header.Get("Content-Type") == jsonContentType &&
+			string(reqBody) == body
+	}
+}
+
+func TestProxyHandlerAsync(t *testing.T) {
+	testCtx := newTestAsyncContext()
+	testTask := router.TaskMap{TaskBody: testAsyncReqBody}
+	mHttpClient := new(mockHttpClient)
+	testHandler, work, _ := newTestAsyncProxyHandler(mHttpClient)
+
+	mHttpClient.On("Do", mock.MatchedBy(isFunctionCall)).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader("Backed up.")),
+	}, nil)
+	mHttpClient.On("Do", mock.MatchedBy(isResponseUrlPost(`{"response_type":"ephemeral","text":"Backed up."}`))).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader("ok")),
+	}, nil)
+
+	testHandler.Execute(&testCtx, &testTask)
+	assert.Equal(t, testTask.Body(), "Backing up...")
+	assert.Nil(t, testTask.Err())
+	assert.Len(t, *work, 1)
+	mHttpClient.AssertNotCalled(t, "Do", mock.Anything)
+
+	(*work)[0]()
+	mHttpClient.AssertNumberOfCalls(t, "Do", 2)
+}
+
+func TestProxyHandlerAsyncDefaultAcknowledgement(t *testing.T) {
+	testCtx := newTestAsyncContext()
+	delete(testCtx, requestAck)
+	testTask := router.TaskMap{TaskBody: testAsyncReqBody}
+	testHandler, work, _ := newTestAsyncProxyHandler(new(mockHttpClient))
+
+	testHandler.Execute(&testCtx, &testTask)
+	assert.Equal(t, testTask.Body(), defaultAckMsg)
+	assert.Len(t, *work, 1)
+}
+
+func TestProxyHandlerAsyncSlackMessage(t *testing.T) {
+	testCtx := newTestAsyncContext()
+	testTask := router.TaskMap{TaskBody: testAsyncReqBody}
+	mHttpClient := new(mockHttpClient)
+	testHandler, work, _ := newTestAsyncProxyHandler(mHttpClient)
+	testMsg := `{"response_type":"in_channel","text":"Backed up."}`
+
+	mHttpClient.On("Do", mock.MatchedBy(isFunctionCall)).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(testMsg + "\n")),
+	}, nil)
+	mHttpClient.On("Do", mock.MatchedBy(isResponseUrlPost(testMsg))).Return(&http.Response{StatusCode: http.StatusOK}, nil)
+
+	testHandler.Execute(&testCtx, &testTask)
+	(*work)[0]()
+	mHttpClient.AssertNumberOfCalls(t, "Do", 2)
+}
+
+func TestProxyHandlerAsyncFunctionError(t *testing.T) {
+	testCtx := newTestAsyncContext()
+	testTask := router.TaskMap{TaskBody: testAsyncReqBody}
+	mHttpClient := new(mockHttpClient)
+	testHandler, work, _ := newTestAsyncProxyHandler(mHttpClient)
+
+	mHttpClient.On("Do", mock.MatchedBy(isFunctionCall)).Return(&http.Response{}, context.DeadlineExceeded)
+	mHttpClient.On("Do", mock.MatchedBy(isResponseUrlPost(`{"response_type":"ephemeral","text":"`+timeoutErrRespMsg+`"}`))).Return(&http.Response{StatusCode: http.StatusOK}, nil)
+
+	testHandler.Execute(&testCtx, &testTask)
+	(*work)[0]()
+	mHttpClient.AssertNumberOfCalls(t, "Do", 2)
+}
+
+func TestProxyHandlerAsyncUpstreamStatus(t *testing.T) {
+	testCtx := newTestAsyncContext()
+	testTask := router.TaskMap{TaskBody: testAsyncReqBody}
+	mHttpClient := new(mockHttpClient)
+	testHandler, work, _ := newTestAsyncProxyHandler(mHttpClient)
+
+	mHttpClient.On("Do", mock.MatchedBy(isFunctionCall)).Return(&http.Response{
+		StatusCode: http.StatusInternalServerError,
+		Body:       ioutil.NopCloser(strings.NewReader("panic: nil map")),
+	}, nil)
+	mHttpClient.On("Do", mock.MatchedBy(isResponseUrlPost(`{"response_type":"ephemeral","text":"`+upstreamErrRespMsg+`"}`))).Return(&http.Response{StatusCode: http.StatusOK}, nil)
+
+	testHandler.Execute(&testCtx, &testTask)
+	(*work)[0]()
+	mHttpClient.AssertNumberOfCalls(t, "Do", 2)
+}
+
+func TestProxyHandlerAsyncDeliveryRetries(t *testing.T) {
+	testCases := []struct {
+		responses []*http.Response
+		errs      []error
+		calls     int
+		sleeps    []time.Duration
+	}{
+		{
+			[]*http.Response{{StatusCode: http.StatusBadGateway, Status: "502 Bad Gateway"}, {StatusCode: http.StatusOK}},
+			[]error{nil, nil},
+			2,
+			[]time.Duration{time.Second},
+		},
+		{
+			[]*http.Response{{}, {StatusCode: http.StatusTooManyRequests}, {StatusCode: http.StatusServiceUnavailable}},
+			[]error{errors.New("connection reset"), nil, nil},
+			3,
+			[]time.Duration{time.Second, 2 * time.Second},
+		},
+		{
+			[]*http.Response{{StatusCode: http.StatusNotFound, Status: "404 Not Found"}},
+			[]error{nil},
+			1,
+			nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCtx := newTestAsyncContext()
+		testTask := router.TaskMap{TaskBody: testAsyncReqBody}
+		mHttpClient := new(mockHttpClient)
+		testHandler, work, sleeps := newTestAsyncProxyHandler(mHttpClient)
+
+		mHttpClient.On("Do", mock.MatchedBy(isFunctionCall)).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader("Backed up.")),
+		}, nil)
+		for i, resp := range testCase.responses {
+			mHttpClient.On("Do", mock.MatchedBy(isResponseUrl)).Return(resp, testCase.errs[i]).Once()
+		}
+
+		testHandler.Execute(&testCtx, &testTask)
+		(*work)[0]()
+		mHttpClient.AssertNumberOfCalls(t, "Do", testCase.calls+1)
+		assert.Equal(t, *sleeps, testCase.sleeps)
+	}
+}
+
+func TestProxyHandlerAsyncWithoutResponseUrl(t *testing.T) {
+	testCtx := newTestAsyncContext()
+	delete(testCtx, router.RequestContextKey)
+	testTask := router.TaskMap{TaskBody: "command=%2Fops&text=backup"}
+	mHttpClient := new(mockHttpClient)
+	testHandler, work, _ := newTestAsyncProxyHandler(mHttpClient)
+
+	mHttpClient.On("Do", mock.MatchedBy(isFunctionCall)).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(testProxyBody)),
+	}, nil)
+
+	testHandler.Execute(&testCtx, &testTask)
+	assert.Equal(t, testTask.Body(), testProxyBody)
+	assert.Empty(t, *work)
+}
+
+func TestProxyHandlerAsyncRefusedResponseUrl(t *testing.T) {
+	for _, responseUrl := range []string{
+		"http://hooks.slack.com/commands/T1/123/abc",
+		"https://hooks.slack.com.example.com/commands/T1/123/abc",
+		"https://169.254.169.254/latest/meta-data/",
+		"not a url",
+	} {
+		testCtx := newTestAsyncContext()
+		delete(testCtx, router.RequestContextKey)
+		testTask := router.TaskMap{TaskBody: "command=%2Fops&text=backup&response_url=" + url.QueryEscape(responseUrl)}
+		mHttpClient := new(mockHttpClient)
+		testHandler, work, _ := newTestAsyncProxyHandler(mHttpClient)
+
+		mHttpClient.On("Do", mock.MatchedBy(isFunctionCall)).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(testProxyBody)),
+		}, nil)
+
+		testHandler.Execute(&testCtx, &testTask)
+		assert.Equal(t, testTask.Body(), testProxyBody, responseUrl)
+		assert.Empty(t, *work, responseUrl)
+		mHttpClient.AssertNumberOfCalls(t, "Do", 1)
+	}
+}
+
+func TestProxyHandlerAsyncWithoutDispatcher(t *testing.T) {
+	testCtx := newTestAsyncContext()
+	delete(testCtx, router.RequestContextKey)
+	testTask := router.TaskMap{TaskBody: testAsyncReqBody}
+	mHttpClient := new(mockHttpClient)
+	testHandler := NewProxyHandler(mHttpClient)
+
+	mHttpClient.On("Do", mock.MatchedBy(isFunctionCall)).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(testProxyBody)),
+	}, nil)
+
+	testHandler.Execute(&testCtx, &testTask)
+	assert.Equal(t, testTask.Body(), testProxyBody)
+	mHttpClient.AssertNumberOfCalls(t, "Do", 1)
+}
+
+func TestProxyHandlerAsyncResponseClient(t *testing.T) {
+	testHandler := NewProxyHandlerWithAsyncOptions(new(mockHttpClient), AsyncOptions{Dispatch: GoDispatcher})
+	assert.Equal(t, testHandler.async.Client, &http.Client{Timeout: defaultResponseTimeout})
+	assert.Equal(t, testHandler.async.ResponseHosts, []string{defaultResponseHost})
+	assert.Equal(t, testHandler.async.CallTimeout, defaultCallTimeout)
+}
+
+func TestProxyHandlerAsyncCallTimeout(t *testing.T) {
+	testCtx := newTestAsyncContext()
+	testTask := router.TaskMap{TaskBody: testAsyncReqBody}
+	mHttpClient := new(mockHttpClient)
+	var work []func()
+	testHandler := NewProxyHandlerWithAsyncOptions(mHttpClient, AsyncOptions{
+		Dispatch:    func(w func()) { work = append(work, w) },
+		Client:      mHttpClient,
+		CallTimeout: 10 * time.Millisecond,
+	})
+
+	mHttpClient.On("Do", mock.MatchedBy(isFunctionCall)).Run(func(args mock.Arguments) {
+		<-args.Get(0).(*http.Request).Context().Done()
+	}).Return(&http.Response{}, context.DeadlineExceeded)
+	mHttpClient.On("Do", mock.MatchedBy(isResponseUrlPost(`{"response_type":"ephemeral","text":"`+timeoutErrRespMsg+`"}`))).Return(&http.Response{StatusCode: http.StatusOK}, nil)
+
+	testHandler.Execute(&testCtx, &testTask)
+	assert.Len(t, work, 1)
+	work[0]()
+	mHttpClient.AssertNumberOfCalls(t, "Do", 2)
+}
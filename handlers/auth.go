@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"github.com/phoenixcoder/slack-golang-sdk/slashcmd"
+	"net/url"
+)
+
+const (
+	slashCmdTeamField    = "team_id"
+	slashCmdChannelField = "channel_id"
+	slashCmdUserField    = "user_id"
+
+	CallerKey = "caller"
+)
+
+type accessRegistryInterface interface {
+	AccessLists(cmd *slashcmd.Info) ([]*router.AccessList, error)
+}
+
+// AuthorizationHandler refuses callers that the access lists of the
+// command, and of the groups and function it names, do not allow. It
+// should come after the SlackSignatureHandler, so that the caller's ids
+// can be trusted, and before the HelpHandler and RegistryHandler.
+type AuthorizationHandler struct {
+	registry accessRegistryInterface
+	resolver router.UserGroupResolver
+}
+
+// NewAuthorizationHandler is a factory method for creating the
+// authorization handler. Without a resolver, callers are only allowed by
+// user groups if their user id is also listed.
+func NewAuthorizationHandler(registry accessRegistryInterface) AuthorizationHandler {
+	return NewAuthorizationHandlerWithResolver(registry, nil)
+}
+
+// NewAuthorizationHandlerWithResolver is a factory method for creating the
+// authorization handler that checks user group membership with the given
+// resolver.
+func NewAuthorizationHandlerWithResolver(registry accessRegistryInterface, resolver router.UserGroupResolver) AuthorizationHandler {
+	return AuthorizationHandler{
+		registry: registry,
+		resolver: resolver,
+	}
+}
+
+// Before method that writes the caller into the context, and stops the
+// request with the forbidden response if any access list refuses the
+// caller. Requests for unknown commands, or that can not be parsed, are
+// left to the RegistryHandler to report.
+func (a *AuthorizationHandler) Before(context *router.ContextMap, task *router.TaskMap) bool {
+	cmd, err := slashCommand(context, task)
+	if err != nil {
+		return false
+	}
+	form, err := slashCommandForm(context, task)
+	if err != nil {
+		return false
+	}
+	caller := formCaller(form)
+	(*context)[CallerKey] = caller
+
+	registry := registrySnapshot(context, a.registry).(accessRegistryInterface)
+	lists, err := registry.AccessLists(cmd)
+	if err != nil {
+		return false
+	}
+
+	for _, list := range lists {
+		allowed, err := list.Allows(context.Context(), caller, a.resolver)
+		if err != nil {
+			setInternalErrCode(task, err.Error())
+			return true
+		}
+		if !allowed {
+			setForbiddenErrCode(task)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Execute method that does nothing.
+func (a *AuthorizationHandler) Execute(context *router.ContextMap, task *router.TaskMap) {}
+
+// After method that does nothing.
+func (a *AuthorizationHandler) After(context *router.ContextMap, task *router.TaskMap) {}
+
+// formCaller reads the ids of the team, channel and user that invoked the
+// slash command out of the form Slack sends with it.
+func formCaller(form url.Values) router.Caller {
+	return router.Caller{
+		TeamID:    form.Get(slashCmdTeamField),
+		ChannelID: form.Get(slashCmdChannelField),
+		UserID:    form.Get(slashCmdUserField),
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+const testAccessRegJson = `{
+           "/deploy": {
+               "access" : {"teams" : ["T1"]},
+               "functions" : {
+                   "ship" : {
+                       "usage" : "/deploy ship <service>",
+                       "access" : {"channels" : ["C-deploy"], "userGroups" : ["S-release"]}
+                   },
+                   "status" : {
+                       "usage" : "/deploy status"
+                   }
+               }
+           }
+        }`
+
+type failingGroupResolver struct{}
+
+func (r failingGroupResolver) IsMember(ctx context.Context, caller router.Caller, group string) (bool, error) {
+	return false, errors.New("resolver unavailable")
+}
+
+func newTestAuthorizationHandler(t *testing.T, resolver router.UserGroupResolver) AuthorizationHandler {
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(testAccessRegJson))
+	assert.Nil(t, err)
+
+	return NewAuthorizationHandlerWithResolver(cmdReg, resolver)
+}
+
+func TestAuthorizationHandler(t *testing.T) {
+	testHandler := newTestAuthorizationHandler(t, router.UserGroupMap{"S-release": {"U-releaser"}})
+	testCases := []struct {
+		body    string
+		allowed bool
+	}{
+		{"command=%2Fdeploy&text=ship+api&team_id=T1&channel_id=C-deploy&user_id=U-releaser", true},
+		{"command=%2Fdeploy&text=ship+api&team_id=T1&channel_id=C-deploy&user_id=U-intern", false},
+		{"command=%2Fdeploy&text=ship+api&team_id=T1&channel_id=C-random&user_id=U-releaser", false},
+		{"command=%2Fdeploy&text=status&team_id=T1&channel_id=C-random&user_id=U-intern", true},
+		{"command=%2Fdeploy&text=status&team_id=T2&channel_id=C-deploy&user_id=U-releaser", false},
+		{"command=%2Fdeploy&text=help&team_id=T2", false},
+		{"command=%2Fbuild&text=ship&team_id=T2", true},
+		{"text=ship&team_id=T2", true},
+	}
+
+	for _, testCase := range testCases {
+		testCtx := make(router.ContextMap)
+		testTask := router.TaskMap{TaskBody: testCase.body}
+
+		assert.Equal(t, testHandler.Before(&testCtx, &testTask), !testCase.allowed, testCase.body)
+		statusCode, hasStatusCode := testTask.StatusCode()
+		if testCase.allowed {
+			assert.False(t, hasStatusCode, testCase.body)
+		} else {
+			assert.Equal(t, statusCode, http.StatusForbidden, testCase.body)
+		}
+	}
+}
+
+func TestAuthorizationHandlerCaller(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fdeploy&text=status&team_id=T1&channel_id=C1&user_id=U1"}
+	testHandler := newTestAuthorizationHandler(t, nil)
+
+	assert.False(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testCtx[CallerKey], router.Caller{TeamID: "T1", ChannelID: "C1", UserID: "U1"})
+}
+
+func TestAuthorizationHandlerResolverError(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fdeploy&text=ship&team_id=T1&channel_id=C-deploy&user_id=U1"}
+	testHandler := newTestAuthorizationHandler(t, failingGroupResolver{})
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	statusCode, _ := testTask.StatusCode()
+	assert.Equal(t, statusCode, http.StatusInternalServerError)
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// TODO Move to a separate package.
+	forbiddenErrRespMsg   = "uh uh uh...you didn't say the magic word."
+	internalErrRespMsg    = "Sorry...we uh...messed up."
+	badRequestErrRespMsg  = "Hmm...we couldn't make sense of that."
+	notFoundErrRespMsg    = "Never heard of that one."
+	cmdNotFoundErrRespMsg = "Never heard of that command."
+	upstreamErrRespMsg    = "The function we called let us down."
+	timeoutErrRespMsg     = "That took way too long. We gave up."
+	funcNotFoundErrMsgFmt = "We're embarrassed for you, but we don't know a '%s'. Try these instead:\n'%s'"
+	funcSuggestErrMsgFmt  = "We're embarrassed for you, but we don't know a '%s'. Did you mean '%s'?"
+	argsNotFoundErrMsgFmt = "You'll have to tell us what to do. Try one of these:\n'%s'"
+	reservedErrMsgFmt     = "'%s' is reserved. Try '%s help' instead."
+	argsErrMsgFmt         = "%s\nUsage: `%s`"
+	logMsg                = "%s, %s"
+)
+
+// ErrorKind classifies an error recorded on a task, and decides the status
+// code and message of the response rendered for it.
+type ErrorKind int
+
+const (
+	KindInternal ErrorKind = iota
+	KindNotFound
+	KindForbidden
+	KindUpstream
+	KindTimeout
+	KindBadRequest
+	// KindUsage is for requests that name a function the command does not
+	// have, or give it the wrong arguments. It is rendered as an ephemeral
+	// message with a 200 status, as Slack does not show the user the body
+	// of any other status.
+	KindUsage
+)
+
+var kindResponses = map[ErrorKind]struct {
+	statusCode int
+	msg        string
+}{
+	KindInternal:   {http.StatusInternalServerError, internalErrRespMsg},
+	KindNotFound:   {http.StatusNotFound, notFoundErrRespMsg},
+	KindForbidden:  {http.StatusForbidden, forbiddenErrRespMsg},
+	KindUpstream:   {http.StatusBadGateway, upstreamErrRespMsg},
+	KindTimeout:    {http.StatusGatewayTimeout, timeoutErrRespMsg},
+	KindBadRequest: {http.StatusBadRequest, badRequestErrRespMsg},
+	KindUsage:      {http.StatusOK, badRequestErrRespMsg},
+}
+
+// Error is an error tagged with its kind and, optionally, the message to
+// show the user in place of the kind's default message.
+type Error struct {
+	Kind    ErrorKind
+	Message string
+	Err     error
+}
+
+// NewError is a factory method for creating an error of the given kind
+// that wraps the underlying error.
+func NewError(kind ErrorKind, message string, err error) *Error {
+	return &Error{
+		Kind:    kind,
+		Message: message,
+		Err:     err,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKindOf classifies an error. Errors created with NewError keep their
+// kind, unknown commands are not found, unknown functions and missing,
+// reserved and malformed arguments are usage errors, deadline and network
+// timeouts are timeouts, failed http calls and error statuses from
+// functions are upstream failures, and anything else is internal.
+func ErrorKindOf(err error) ErrorKind {
+	var kindErr *Error
+	if errors.As(err, &kindErr) {
+		return kindErr.Kind
+	}
+
+	var cmdErr router.CommandNotFoundError
+	if errors.As(err, &cmdErr) {
+		return KindNotFound
+	}
+
+	var funcErr router.FunctionNotFoundError
+	var argsErr router.ArgsNotFoundError
+	var reservedErr router.ReservedKeywordError
+	var argErr router.ArgumentError
+	if errors.As(err, &funcErr) || errors.As(err, &argsErr) || errors.As(err, &reservedErr) || errors.As(err, &argErr) {
+		return KindUsage
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return KindTimeout
+	}
+
+	var urlErr *url.Error
+	var statusErr UpstreamStatusError
+	if errors.As(err, &urlErr) || errors.As(err, &statusErr) {
+		return KindUpstream
+	}
+
+	return KindInternal
+}
+
+// ErrorHandler renders the error recorded on a task into a response with a
+// status code and user-facing message. It should be the first handler in
+// the chain, so that it sees errors recorded by every handler after it.
+type ErrorHandler struct{}
+
+// NewErrorHandler is a factory method for creating the error handler.
+func NewErrorHandler() ErrorHandler {
+	return ErrorHandler{}
+}
+
+// Before method that does nothing.
+func (e *ErrorHandler) Before(context *router.ContextMap, task *router.TaskMap) bool {
+	return false
+}
+
+// Execute method that does nothing.
+func (e *ErrorHandler) Execute(context *router.ContextMap, task *router.TaskMap) {}
+
+// After method that renders the task's error, if there is one. Errors
+// already rendered by the handler that recorded them, which set the task's
+// status code, are left as they are so they are not rendered or logged
+// twice.
+func (e *ErrorHandler) After(context *router.ContextMap, task *router.TaskMap) {
+	err := task.Err()
+	if err == nil {
+		return
+	}
+	if _, rendered := task.StatusCode(); rendered {
+		return
+	}
+
+	setErrResponse(task, err)
+}
+
+// recordErr records the error on the task for the ErrorHandler, and
+// renders its response straight away, so that the slash command in the
+// task's body is never echoed back to the caller when there is no
+// ErrorHandler in the chain.
+func recordErr(task *router.TaskMap, err error) {
+	task.SetErr(err)
+	setErrResponse(task, err)
+}
+
+// setErrResponse sets the status code and body of the task for the kind
+// of the error. A message specific to the error replaces the default one.
+// Usage errors are rendered as an ephemeral Slack message.
+func setErrResponse(task *router.TaskMap, err error) {
+	kind := ErrorKindOf(err)
+	resp := kindResponses[kind]
+	if kind == KindUsage {
+		msg := errRespMsg(err)
+		if msg == "" {
+			msg = resp.msg
+		}
+		task.SetStatusCode(resp.statusCode)
+		task.SetResponseHeader(contentTypeHeader, jsonContentType)
+		task.SetBody(string(ephemeralMessage(msg)))
+		log.Printf(logMsg, msg, err.Error())
+		return
+	}
+
+	if msg := errRespMsg(err); msg != "" {
+		task.SetStatusCode(resp.statusCode)
+		task.SetBody(msg)
+		log.Printf(logMsg, msg, err.Error())
+		return
+	}
+
+	setErredStatusCode(task, resp.msg, err.Error(), resp.statusCode)
+}
+
+// errRespMsg returns the user-facing message for errors that carry one,
+// or suggest and list the functions the user can choose from instead. The
+// names, usage and user input put in the message are escaped, as Slack
+// reads it as formatted text.
+func errRespMsg(err error) string {
+	var kindErr *Error
+	if errors.As(err, &kindErr) && kindErr.Message != "" {
+		return kindErr.Message
+	}
+
+	var funcErr router.FunctionNotFoundError
+	if errors.As(err, &funcErr) {
+		if len(funcErr.Suggestions) > 0 {
+			return fmt.Sprintf(funcSuggestErrMsgFmt, slackEscaper.Replace(funcErr.Function), joinEscaped(funcErr.Suggestions))
+		}
+		return fmt.Sprintf(funcNotFoundErrMsgFmt, slackEscaper.Replace(funcErr.Function), joinEscaped(funcErr.Functions))
+	}
+
+	var argsErr router.ArgsNotFoundError
+	if errors.As(err, &argsErr) {
+		return fmt.Sprintf(argsNotFoundErrMsgFmt, joinEscaped(argsErr.Functions))
+	}
+
+	var reservedErr router.ReservedKeywordError
+	if errors.As(err, &reservedErr) {
+		return fmt.Sprintf(reservedErrMsgFmt, slackEscaper.Replace(reservedErr.Keyword), slackEscaper.Replace(reservedErr.Command))
+	}
+
+	var argErr router.ArgumentError
+	if errors.As(err, &argErr) {
+		return fmt.Sprintf(argsErrMsgFmt, slackEscaper.Replace(argErr.Msg), slackEscaper.Replace(argErr.Usage))
+	}
+
+	var cmdErr router.CommandNotFoundError
+	if errors.As(err, &cmdErr) {
+		return cmdNotFoundErrRespMsg
+	}
+
+	return ""
+}
+
+// joinEscaped escapes the names for Slack and lists them between quotes.
+func joinEscaped(names []string) string {
+	escaped := make([]string, len(names))
+	for i, name := range names {
+		escaped[i] = slackEscaper.Replace(name)
+	}
+
+	return strings.Join(escaped, "', '")
+}
+
+// TODO Move to a separate package.
+func setInternalErrCode(task *router.TaskMap, reason string) {
+	setErredStatusCode(task, internalErrRespMsg, reason, http.StatusInternalServerError)
+}
+
+// TODO Move to a separate package.
+func setForbiddenErrCode(task *router.TaskMap) {
+	setErredStatusCode(task, forbiddenErrRespMsg, "You're just not allowed.", http.StatusForbidden)
+}
+
+// TODO Move to a separate package.
+func setErredStatusCode(task *router.TaskMap, msg string, reason string, statusCode int) {
+	task.SetStatusCode(statusCode)
+	task.SetBody(msg + " (" + http.StatusText(statusCode) + ")")
+	log.Printf(logMsg, task.Body(), reason)
+}
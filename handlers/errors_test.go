@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestErrorKindOf(t *testing.T) {
+	testErr := errors.New("Test Error")
+	testCases := []struct {
+		err  error
+		kind ErrorKind
+	}{
+		{testErr, KindInternal},
+		{NewError(KindForbidden, "", testErr), KindForbidden},
+		{fmt.Errorf("wrapped: %w", NewError(KindBadRequest, "", testErr)), KindBadRequest},
+		{context.DeadlineExceeded, KindTimeout},
+		{&url.Error{Op: "Post", URL: testProxyUrl, Err: context.DeadlineExceeded}, KindTimeout},
+		{&url.Error{Op: "Post", URL: testProxyUrl, Err: testErr}, KindUpstream},
+		{UpstreamStatusError{Url: testProxyUrl, StatusCode: http.StatusInternalServerError}, KindUpstream},
+		{router.CommandNotFoundError{}, KindNotFound},
+		{fmt.Errorf("wrapped: %w", router.FunctionNotFoundError{}), KindUsage},
+		{router.ArgsNotFoundError{}, KindUsage},
+		{router.ReservedKeywordError{}, KindUsage},
+		{router.ArgumentError{}, KindUsage},
+	}
+
+	for _, testCase := range testCases {
+		assert.Equal(t, ErrorKindOf(testCase.err), testCase.kind, testCase.err.Error())
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	testCases := []struct {
+		err        error
+		statusCode int
+		body       string
+	}{
+		{errors.New("Test Error"), http.StatusInternalServerError, internalErrRespMsg + " (Internal Server Error)"},
+		{NewError(KindNotFound, "", nil), http.StatusNotFound, notFoundErrRespMsg + " (Not Found)"},
+		{NewError(KindForbidden, "", nil), http.StatusForbidden, forbiddenErrRespMsg + " (Forbidden)"},
+		{&url.Error{Op: "Post", URL: testProxyUrl, Err: errors.New("refused")}, http.StatusBadGateway, upstreamErrRespMsg + " (Bad Gateway)"},
+		{context.DeadlineExceeded, http.StatusGatewayTimeout, timeoutErrRespMsg + " (Gateway Timeout)"},
+		{NewError(KindBadRequest, "Custom message.", nil), http.StatusBadRequest, "Custom message."},
+		{router.CommandNotFoundError{Command: "/build"}, http.StatusNotFound, cmdNotFoundErrRespMsg},
+		{router.FunctionNotFoundError{Command: "/deploy", Function: "launch", Functions: []string{"rollback", "ship"}},
+			http.StatusOK, string(ephemeralMessage("We're embarrassed for you, but we don't know a 'launch'. Try these instead:\n'rollback', 'ship'"))},
+		{router.FunctionNotFoundError{Command: "/deploy", Function: "shp", Functions: []string{"rollback", "ship"}, Suggestions: []string{"ship"}},
+			http.StatusOK, string(ephemeralMessage("We're embarrassed for you, but we don't know a 'shp'. Did you mean 'ship'?"))},
+		{router.FunctionNotFoundError{Command: "/deploy", Function: "<!channel>&", Functions: []string{"ship"}, Suggestions: []string{"<ship>"}},
+			http.StatusOK, string(ephemeralMessage("We're embarrassed for you, but we don't know a '&lt;!channel&gt;&amp;'. Did you mean '&lt;ship&gt;'?"))},
+		{router.ArgsNotFoundError{Command: "/deploy", Functions: []string{"ship"}},
+			http.StatusOK, string(ephemeralMessage("You'll have to tell us what to do. Try one of these:\n'ship'"))},
+		{router.ArgumentError{Function: "ship", Usage: "/deploy ship <service>", Msg: "Missing argument 'service'."},
+			http.StatusOK, string(ephemeralMessage("Missing argument 'service'.\nUsage: `/deploy ship &lt;service&gt;`"))},
+	}
+	testHandler := NewErrorHandler()
+
+	for _, testCase := range testCases {
+		testCtx := make(router.ContextMap)
+		testTask := make(router.TaskMap)
+		testTask.SetErr(testCase.err)
+
+		assert.False(t, testHandler.Before(&testCtx, &testTask))
+		testHandler.After(&testCtx, &testTask)
+
+		statusCode, _ := testTask.StatusCode()
+		assert.Equal(t, statusCode, testCase.statusCode)
+		assert.Equal(t, testTask.Body(), testCase.body)
+	}
+}
+
+func TestErrorHandlerNoError(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{
+		TaskBody: testProxyBody,
+	}
+	testHandler := NewErrorHandler()
+
+	testHandler.After(&testCtx, &testTask)
+
+	_, hasStatusCode := testTask.StatusCode()
+	assert.False(t, hasStatusCode)
+	assert.Equal(t, testTask.Body(), testProxyBody)
+}
+
+func TestErrorHandlerRenderedError(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	testCtx := make(router.ContextMap)
+	testTask := make(router.TaskMap)
+	testHandler := NewErrorHandler()
+	recordErr(&testTask, router.CommandNotFoundError{Command: "/build"})
+	testHandler.After(&testCtx, &testTask)
+
+	statusCode, _ := testTask.StatusCode()
+	assert.Equal(t, statusCode, http.StatusNotFound)
+	assert.Equal(t, testTask.Body(), cmdNotFoundErrRespMsg)
+	assert.Equal(t, strings.Count(logs.String(), cmdNotFoundErrRespMsg), 1)
+}
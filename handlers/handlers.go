@@ -1,47 +1,34 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"github.com/phoenixcoder/serverless-request-router/router"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"strings"
+	"time"
 )
 
 const (
 	requestUrl        = "request-url"
+	requestMethod     = "request-method"
+	requestHeaders    = "request-headers"
+	requestTimeout    = "request-timeout"
 	contentTypeHeader = "content-type"
 
-	// TODO Move to a separate package.
-	forbiddenErrRespMsg   = "uh uh uh...you didn't say the magic word."
-	internalErrRespMsg    = "Sorry...we uh...messed up."
-	funcNotFoundErrMsgFmt = "We're embarrassed for you, but we don't know a '%s'. Try these instead:\n'%s'"
-	logMsg                = "%s, %s"
-	ErrorKey              = "error"
-	TaskBody              = "body"
+	ErrorKey            = router.TaskErrorKey
+	TaskBody            = router.TaskBodyKey
+	TaskHeaders         = router.TaskHeadersKey
+	SlashCommandKey     = "slash-command"
+	SlashCommandFormKey = "slash-command-form"
+	FunctionArgsKey     = "function-arguments"
+	RegistrySnapshotKey = "registry-snapshot"
 )
 
-// TODO Move to a separate package.
-func setInternalErrCode(task *router.TaskMap, reason string) {
-	setErredStatusCode(task, internalErrRespMsg, reason, http.StatusInternalServerError)
-}
-
-// TODO Move to a separate package.
-func setForbiddenErrCode(task *router.TaskMap) {
-	setErredStatusCode(task, forbiddenErrRespMsg, "You're just not allowed.", http.StatusForbidden)
-}
-
-// TODO Move to a separate package.
-func setErredStatusCode(task *router.TaskMap, msg string, reason string, statusCode int) {
-	(*task)["StatusCode"] = statusCode
-	(*task)["Body"] = msg + " (" + http.StatusText(statusCode) + ")"
-	log.Printf(logMsg, (*task)["Body"], reason)
-}
-
 type httpClientInterface interface {
-	Post(url string, contentType string, body io.Reader) (*http.Response, error)
+	Do(req *http.Request) (*http.Response, error)
 }
 
 // ProxyHandler is a wrapper for the http client and process that
@@ -51,14 +38,93 @@ type ProxyHandler struct {
 	requestUrl        string
 	contentTypeHeader string
 	client            httpClientInterface
+	async             AsyncOptions
+	responseHeaders   []string
+	templateEnv       []string
+	statusPolicy      StatusPolicy
+	sleep             func(time.Duration)
+}
+
+// ProxyOptions configures how the ProxyHandler calls functions and passes
+// their responses back.
+type ProxyOptions struct {
+	// Async configures how asynchronous functions are called.
+	Async AsyncOptions
+	// ResponseHeaders are the headers of a function's response passed
+	// through to the task, in addition to its Content-Type.
+	ResponseHeaders []string
+	// StatusPolicy decides which statuses of a function's response are
+	// recorded on the task as an UpstreamStatusError.
+	StatusPolicy StatusPolicy
+	// TemplateEnv are the environment variables the headers and query of
+	// a function may name as {env.NAME}. Requests to functions whose
+	// templates name any other variable are refused.
+	TemplateEnv []string
+}
+
+// DefaultProxyOptions returns the options used by NewProxyHandler.
+func DefaultProxyOptions() ProxyOptions {
+	return ProxyOptions{
+		Async:        DefaultAsyncOptions(),
+		StatusPolicy: Non2xxIsError,
+	}
 }
 
 // NewProxyHandler is a factory method for creating the proxy handler with
-// a separately configured http client.
+// a separately configured http client. Asynchronous functions are called
+// synchronously, as no dispatcher is set, and responses outside the 2xx
+// range are errors.
 func NewProxyHandler(client httpClientInterface) ProxyHandler {
+	return NewProxyHandlerWithOptions(client, DefaultProxyOptions())
+}
+
+// NewProxyHandlerWithAsyncOptions is a factory method for creating the
+// proxy handler with a separately configured http client, and options for
+// calling asynchronous functions.
+func NewProxyHandlerWithAsyncOptions(client httpClientInterface, opts AsyncOptions) ProxyHandler {
+	proxyOpts := DefaultProxyOptions()
+	proxyOpts.Async = opts
+
+	return NewProxyHandlerWithOptions(client, proxyOpts)
+}
+
+// NewProxyHandlerWithOptions is a factory method for creating the proxy
+// handler with a separately configured http client and the given options.
+// The Backoff, Timeout, CallTimeout, ResponseHosts and Client of the async
+// options, and the status policy, take their defaults when they are not
+// set.
+func NewProxyHandlerWithOptions(client httpClientInterface, opts ProxyOptions) ProxyHandler {
+	defaults := DefaultProxyOptions()
+	if opts.Async.Backoff <= 0 {
+		opts.Async.Backoff = defaults.Async.Backoff
+	}
+	if opts.Async.Timeout <= 0 {
+		opts.Async.Timeout = defaults.Async.Timeout
+	}
+	if opts.Async.CallTimeout <= 0 {
+		opts.Async.CallTimeout = defaults.Async.CallTimeout
+	}
+	if opts.Async.Retries < 0 {
+		opts.Async.Retries = 0
+	}
+	if len(opts.Async.ResponseHosts) == 0 {
+		opts.Async.ResponseHosts = defaults.Async.ResponseHosts
+	}
+	if opts.Async.Client == nil {
+		opts.Async.Client = &http.Client{Timeout: opts.Async.Timeout}
+	}
+	if opts.StatusPolicy == nil {
+		opts.StatusPolicy = defaults.StatusPolicy
+	}
+
 	return ProxyHandler{
-		errMsg: "Request url was not provided when proxying.",
-		client: client,
+		errMsg:          "Request url was not provided when proxying.",
+		client:          client,
+		async:           opts.Async,
+		responseHeaders: opts.ResponseHeaders,
+		templateEnv:     opts.TemplateEnv,
+		statusPolicy:    opts.StatusPolicy,
+		sleep:           time.Sleep,
 	}
 }
 
@@ -68,34 +134,91 @@ func (p *ProxyHandler) Before(context *router.ContextMap, task *router.TaskMap)
 }
 
 // Execute method that inspects the context for a request url and sends
-// an http request to that url. It sends the response of the request
-// back in the task.
+// an http request to that url. The method, headers, query, body transform
+// and timeout of the request are also taken from the context when present,
+// and the request is cancelled along with the router's context.Context.
+// It sends the status, body, Content-Type and selected headers of the
+// response back in the task, or records an UpstreamStatusError if the
+// status policy treats the response's status as an error. Errors are
+// rendered in place of the request body, so that it is never echoed back
+// to the caller. Asynchronous functions are instead acknowledged in the
+// task straight away, and their response is posted to the slash command's
+// response_url once the dispatched call completes. Without a dispatcher,
+// or a response_url on an allowed host, they are called synchronously.
 func (p *ProxyHandler) Execute(context *router.ContextMap, task *router.TaskMap) {
-	body := (*task)[TaskBody]
-	bodyStr, _ := body.(string)
-	contentType := (*context)[contentTypeHeader]
-	contentTypeStr, _ := contentType.(string)
-	requestUrl, requestUrlOk := (*context)[requestUrl]
-	requestUrlStr, requestUrlStrOk := requestUrl.(string)
-	requestUrlOk = requestUrlOk && requestUrlStrOk
+	contentType, _ := context.String(contentTypeHeader)
+	requestUrl, requestUrlOk := context.String(requestUrl)
 	if requestUrlOk {
-		routedResp, err := p.client.Post(requestUrlStr, contentTypeStr, strings.NewReader(bodyStr))
+		preq, err := newProxyRequest(context, task, requestUrl, contentType, p.templateEnv)
+		if err != nil {
+			recordErr(task, err)
+			return
+		}
+
+		if async, _ := (*context)[requestAsync].(bool); async {
+			form, _ := slashCommandForm(context, task)
+			if responseUrl := form.Get(slashCmdResponseUrlField); p.canDispatch(responseUrl) {
+				p.executeAsync(context, task, preq, responseUrl)
+				return
+			}
+		}
+
+		routedResp, cancel, err := p.send(context, preq)
+		defer cancel()
 		if err != nil {
-			(*task)[ErrorKey] = err
+			recordErr(task, err)
 			return
 		}
 
+		defer routedResp.Body.Close()
+
 		routedRespBody, err := ioutil.ReadAll(routedResp.Body)
 		if err != nil {
-			(*task)[ErrorKey] = err
+			recordErr(task, err)
 			return
 		}
 
-		(*task)[TaskBody] = string(routedRespBody)
+		if p.statusPolicy(routedResp.StatusCode) {
+			recordErr(task, UpstreamStatusError{
+				Url:        preq.url,
+				StatusCode: routedResp.StatusCode,
+				Body:       string(routedRespBody),
+			})
+			return
+		}
+
+		task.SetStatusCode(routedResp.StatusCode)
+		p.copyResponseHeaders(routedResp, task)
+		task.SetBody(string(routedRespBody))
 		return
 	}
 
-	(*task)[ErrorKey] = errors.New(p.errMsg)
+	recordErr(task, errors.New(p.errMsg))
+}
+
+// send sends the request to the function with the client, cancelling it
+// along with the context's context.Context or once its timeout passes.
+// The returned cancel function must be called once the response has been
+// read.
+func (p *ProxyHandler) send(ctxMap *router.ContextMap, preq *proxyRequest) (*http.Response, context.CancelFunc, error) {
+	ctx := ctxMap.Context()
+	cancel := context.CancelFunc(func() {})
+	if timeout := ctxMap.Duration(requestTimeout); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	var bodyReader io.Reader
+	if preq.method != http.MethodGet && preq.method != http.MethodHead {
+		bodyReader = strings.NewReader(preq.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, preq.method, preq.url, bodyReader)
+	if err != nil {
+		return nil, cancel, err
+	}
+	req.Header = preq.header
+
+	resp, err := p.client.Do(req)
+	return resp, cancel, err
 }
 
 // After method that does nothing.
@@ -1,15 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"errors"
 	"github.com/phoenixcoder/serverless-request-router/router"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
-	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -37,8 +39,8 @@ func (m *mockReader) Close() error {
 	return args.Error(0)
 }
 
-func (m *mockHttpClient) Post(url string, contentType string, body io.Reader) (*http.Response, error) {
-	args := m.Called(url, contentType, body)
+func (m *mockHttpClient) Do(req *http.Request) (*http.Response, error) {
+	args := m.Called(req)
 	return args[0].(*http.Response), args.Error(1)
 }
 
@@ -46,16 +48,25 @@ func TestProxyHandler(t *testing.T) {
 	testCtx := make(router.ContextMap)
 	testTask := make(router.TaskMap)
 	testResp := &http.Response{
-		Body: ioutil.NopCloser(strings.NewReader(testProxyBody)),
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(testProxyBody)),
 	}
 	mHttpClient := new(mockHttpClient)
 
 	testCtx[requestUrl] = testProxyUrl
 	testCtx[contentTypeHeader] = testProxyContentType
 
+	testTask[TaskBody] = "command=%2Fdeploy&text=ship"
 	testHandler := NewProxyHandler(mHttpClient)
 
-	mHttpClient.On("Post", mock.Anything, mock.Anything, mock.Anything).Return(testResp, nil)
+	isExpectedRequest := func(req *http.Request) bool {
+		body, _ := ioutil.ReadAll(req.Body)
+		return req.Method == http.MethodPost &&
+			req.URL.String() == testProxyUrl &&
+			req.Header.Get("Content-Type") == testProxyContentType &&
+			string(body) == "command=%2Fdeploy&text=ship"
+	}
+	mHttpClient.On("Do", mock.MatchedBy(isExpectedRequest)).Return(testResp, nil)
 
 	testHandler.Execute(&testCtx, &testTask)
 
@@ -64,11 +75,11 @@ func TestProxyHandler(t *testing.T) {
 	assert.Equal(t, testCtx[requestUrl], testProxyUrl)
 	assert.Equal(t, testCtx[contentTypeHeader], testProxyContentType)
 
-	mHttpClient.AssertNumberOfCalls(t, "Post", 1)
+	mHttpClient.AssertNumberOfCalls(t, "Do", 1)
 }
 
-func TestProxyHandlerPostError(t *testing.T) {
-	testError := errors.New("Post Error")
+func TestProxyHandlerSendError(t *testing.T) {
+	testError := errors.New("Send Error")
 	testCtx := make(router.ContextMap)
 	testTask := make(router.TaskMap)
 	testResp := &http.Response{}
@@ -79,19 +90,19 @@ func TestProxyHandlerPostError(t *testing.T) {
 	testResp.Body = mReader
 	testHandler := NewProxyHandler(mHttpClient)
 
-	mHttpClient.On("Post", mock.Anything, mock.Anything, mock.Anything).Return(testResp, testError)
+	mHttpClient.On("Do", mock.Anything).Return(testResp, testError)
 	mReader.On("Read", mock.Anything).Return(0, nil)
 
 	testHandler.Execute(&testCtx, &testTask)
 
 	assert.False(t, testHandler.Before(nil, nil))
-	assert.Nil(t, testTask[TaskBody])
+	assert.Equal(t, testTask[TaskBody], internalErrRespMsg+" (Internal Server Error)")
 	assert.NotNil(t, testTask[ErrorKey])
 	assert.Equal(t, testTask[ErrorKey], testError)
 	assert.Equal(t, testCtx[requestUrl], testProxyUrl)
 	assert.Equal(t, testCtx[contentTypeHeader], testProxyContentType)
 
-	mHttpClient.AssertNumberOfCalls(t, "Post", 1)
+	mHttpClient.AssertNumberOfCalls(t, "Do", 1)
 	mReader.AssertNotCalled(t, "Read", mock.Anything)
 }
 
@@ -107,20 +118,22 @@ func TestProxyHandlerReadingError(t *testing.T) {
 	testResp.Body = mReader
 	testHandler := NewProxyHandler(mHttpClient)
 
-	mHttpClient.On("Post", mock.Anything, mock.Anything, mock.Anything).Return(testResp, nil)
+	mHttpClient.On("Do", mock.Anything).Return(testResp, nil)
 	mReader.On("Read", mock.Anything).Return(0, testError)
+	mReader.On("Close").Return(nil)
 
 	testHandler.Execute(&testCtx, &testTask)
 
 	assert.False(t, testHandler.Before(nil, nil))
-	assert.Nil(t, testTask[TaskBody])
+	assert.Equal(t, testTask[TaskBody], internalErrRespMsg+" (Internal Server Error)")
 	assert.NotNil(t, testTask[ErrorKey])
 	assert.Equal(t, testTask[ErrorKey], testError)
 	assert.Equal(t, testCtx[requestUrl], testProxyUrl)
 	assert.Equal(t, testCtx[contentTypeHeader], testProxyContentType)
 
-	mHttpClient.AssertNumberOfCalls(t, "Post", 1)
+	mHttpClient.AssertNumberOfCalls(t, "Do", 1)
 	mReader.AssertNumberOfCalls(t, "Read", 1)
+	mReader.AssertNumberOfCalls(t, "Close", 1)
 }
 
 func TestProxyHandlerNoRequestUrl(t *testing.T) {
@@ -133,18 +146,175 @@ func TestProxyHandlerNoRequestUrl(t *testing.T) {
 	testResp.Body = mReader
 	testHandler := NewProxyHandler(mHttpClient)
 
-	mHttpClient.On("Post", mock.Anything, mock.Anything, mock.Anything).Return(testResp, nil)
+	mHttpClient.On("Do", mock.Anything).Return(testResp, nil)
 	mReader.On("Read", mock.Anything).Return(0, nil)
 
 	testHandler.Execute(&testCtx, &testTask)
 
 	assert.False(t, testHandler.Before(nil, nil))
-	assert.Nil(t, testTask[TaskBody])
+	assert.Equal(t, testTask[TaskBody], internalErrRespMsg+" (Internal Server Error)")
 	assert.Nil(t, testCtx[requestUrl])
 	assert.NotNil(t, testTask[ErrorKey])
 	assert.Equal(t, testCtx[contentTypeHeader], testProxyContentType)
 	assert.Equal(t, testTask[ErrorKey].(error).Error(), testHandler.errMsg)
 
-	mHttpClient.AssertNotCalled(t, "Post", mock.Anything, mock.Anything, mock.Anything)
+	mHttpClient.AssertNotCalled(t, "Do", mock.Anything)
 	mReader.AssertNotCalled(t, "Read", mock.Anything)
 }
+
+func TestProxyHandlerRequestOptions(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := make(router.TaskMap)
+	testResp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(testProxyBody)),
+	}
+	mHttpClient := new(mockHttpClient)
+
+	testCtx[requestUrl] = testProxyUrl
+	testCtx[requestMethod] = http.MethodPut
+	testCtx[requestHeaders] = map[string]string{"X-Api-Key": "secret"}
+	testCtx[requestTimeout] = time.Second
+	testCtx[contentTypeHeader] = testProxyContentType
+	testTask[TaskBody] = testProxyBody
+
+	testHandler := NewProxyHandler(mHttpClient)
+
+	isExpectedRequest := func(req *http.Request) bool {
+		_, hasDeadline := req.Context().Deadline()
+		return req.Method == http.MethodPut &&
+			req.URL.String() == testProxyUrl &&
+			req.Header.Get("X-Api-Key") == "secret" &&
+			req.Header.Get("Content-Type") == testProxyContentType &&
+			hasDeadline
+	}
+	mHttpClient.On("Do", mock.MatchedBy(isExpectedRequest)).Return(testResp, nil)
+
+	testHandler.Execute(&testCtx, &testTask)
+
+	assert.Equal(t, testTask[TaskBody], testProxyBody)
+	assert.Nil(t, testTask[ErrorKey])
+
+	mHttpClient.AssertNumberOfCalls(t, "Do", 1)
+}
+
+func TestProxyHandlerCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	testCtx := router.ContextMap{
+		router.RequestContextKey: ctx,
+	}
+	testTask := make(router.TaskMap)
+	mHttpClient := new(mockHttpClient)
+
+	testCtx[requestUrl] = testProxyUrl
+	testCtx[contentTypeHeader] = testProxyContentType
+
+	testHandler := NewProxyHandler(mHttpClient)
+
+	isCancelledRequest := func(req *http.Request) bool {
+		return req.Method == http.MethodPost && req.Context().Err() == context.Canceled
+	}
+	mHttpClient.On("Do", mock.MatchedBy(isCancelledRequest)).Return(&http.Response{}, context.Canceled)
+
+	testHandler.Execute(&testCtx, &testTask)
+
+	assert.Equal(t, testTask[ErrorKey], context.Canceled)
+	mHttpClient.AssertNumberOfCalls(t, "Do", 1)
+}
+
+func TestProxyHandlerResponsePassthrough(t *testing.T) {
+	testCtx := router.ContextMap{requestUrl: testProxyUrl}
+	testTask := make(router.TaskMap)
+	testResp := &http.Response{
+		StatusCode: http.StatusCreated,
+		Header: http.Header{
+			"Content-Type": []string{testProxyContentType},
+			"X-Request-Id": []string{"abc123"},
+			"Set-Cookie":   []string{"session=secret"},
+		},
+		Body: ioutil.NopCloser(strings.NewReader(testProxyBody)),
+	}
+	mHttpClient := new(mockHttpClient)
+	testHandler := NewProxyHandlerWithOptions(mHttpClient, ProxyOptions{ResponseHeaders: []string{"x-request-id"}})
+
+	mHttpClient.On("Do", mock.Anything).Return(testResp, nil)
+
+	testHandler.Execute(&testCtx, &testTask)
+
+	statusCode, _ := testTask.StatusCode()
+	assert.Equal(t, statusCode, http.StatusCreated)
+	assert.Equal(t, testTask.Body(), testProxyBody)
+	assert.Equal(t, testTask.ResponseHeaders(), map[string]string{
+		contentTypeHeader: testProxyContentType,
+		"x-request-id":    "abc123",
+	})
+	assert.Nil(t, testTask.Err())
+}
+
+func TestProxyHandlerUpstreamStatus(t *testing.T) {
+	testCases := []struct {
+		policy     StatusPolicy
+		statusCode int
+		isErr      bool
+	}{
+		{nil, http.StatusInternalServerError, true},
+		{nil, http.StatusNotFound, true},
+		{Non2xxIsError, http.StatusMultipleChoices, true},
+		{Non2xxIsError, http.StatusNoContent, false},
+		{ServerErrorIsError, http.StatusNotFound, false},
+		{ServerErrorIsError, http.StatusBadGateway, true},
+		{NeverError, http.StatusInternalServerError, false},
+	}
+
+	for _, testCase := range testCases {
+		testCtx := router.ContextMap{requestUrl: testProxyUrl}
+		testTask := make(router.TaskMap)
+		mHttpClient := new(mockHttpClient)
+		testHandler := NewProxyHandlerWithOptions(mHttpClient, ProxyOptions{StatusPolicy: testCase.policy})
+
+		mHttpClient.On("Do", mock.Anything).Return(&http.Response{
+			StatusCode: testCase.statusCode,
+			Body:       ioutil.NopCloser(strings.NewReader(testProxyBody)),
+		}, nil)
+
+		testHandler.Execute(&testCtx, &testTask)
+
+		statusCode, hasStatusCode := testTask.StatusCode()
+		if testCase.isErr {
+			var statusErr UpstreamStatusError
+			assert.ErrorAs(t, testTask.Err(), &statusErr, testCase.statusCode)
+			assert.Equal(t, statusErr, UpstreamStatusError{Url: testProxyUrl, StatusCode: testCase.statusCode, Body: testProxyBody})
+			assert.Equal(t, ErrorKindOf(testTask.Err()), KindUpstream)
+			assert.True(t, hasStatusCode)
+			assert.Equal(t, statusCode, http.StatusBadGateway)
+			assert.Equal(t, testTask.Body(), upstreamErrRespMsg+" (Bad Gateway)")
+		} else {
+			assert.Nil(t, testTask.Err(), testCase.statusCode)
+			assert.Equal(t, statusCode, testCase.statusCode)
+			assert.Equal(t, testTask.Body(), testProxyBody)
+		}
+	}
+}
+
+func TestProxyHandlerErrorWithoutErrorHandler(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "panic: nil map", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(testRegJson))
+	assert.Nil(t, err)
+	regHandler := NewRegistryHandler(cmdReg, server.URL+"/")
+	proxyHandler := NewProxyHandler(server.Client())
+	testRouter := router.NewRouter(router.HTTPRequestAdapter, nil, &regHandler, &proxyHandler)
+
+	testBody := "token=SECRETTOKEN&team_id=T1&user_id=U1&command=%2Fdeploy&text=ship+api"
+	testReq := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(testBody))
+	testRec := httptest.NewRecorder()
+	testRouter.ServeHTTP(testRec, testReq)
+
+	assert.Equal(t, testRec.Code, http.StatusBadGateway)
+	assert.Equal(t, testRec.Body.String(), upstreamErrRespMsg+" (Bad Gateway)")
+	assert.NotContains(t, testRec.Body.String(), "SECRETTOKEN")
+}
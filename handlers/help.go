@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"net/http"
+	"strings"
+)
+
+const (
+	helpKeyword         = "help"
+	listKeyword         = "list"
+	textContentType     = "text/plain; charset=utf-8"
+	jsonContentType     = "application/json"
+	ephemeralRespType   = "ephemeral"
+	helpFooterFmt       = "Type `%s help <function>` to learn more about a function."
+	helpManualFmt       = "Manual: %s"
+	helpAliasesFmt      = "Also known as: %s"
+	helpIndent          = "    "
+	helpBullet          = "• "
+	helpNoFunctionsText = "No functions are registered."
+)
+
+// HelpFormat is the format help responses are rendered in.
+type HelpFormat int
+
+const (
+	// HelpText renders help as Slack formatted text.
+	HelpText HelpFormat = iota
+	// HelpBlocks renders help as a Slack message of Block Kit blocks.
+	HelpBlocks
+)
+
+type helpRegistryInterface interface {
+	Help(command string) (*router.CommandHelp, error)
+}
+
+// HelpHandler answers '/cmd help', '/cmd help <function>', '/cmd list' and
+// '/cmd' with no arguments using the usage, description and manual of the
+// functions in the command registry. These are the built-in keywords the
+// registry reserves for the router. Commands and groups that define their
+// own 'help' or 'list' function are left to it. It should come before the
+// RegistryHandler in the chain.
+type HelpHandler struct {
+	registry helpRegistryInterface
+	format   HelpFormat
+}
+
+// NewHelpHandler is a factory method for creating the help handler, which
+// renders help as Slack formatted text.
+func NewHelpHandler(registry helpRegistryInterface) HelpHandler {
+	return NewHelpHandlerWithFormat(registry, HelpText)
+}
+
+// NewHelpHandlerWithFormat is a factory method for creating the help
+// handler that renders help in the given format.
+func NewHelpHandlerWithFormat(registry helpRegistryInterface, format HelpFormat) HelpHandler {
+	return HelpHandler{
+		registry: registry,
+		format:   format,
+	}
+}
+
+// Before method that renders help and stops the request if the slash
+// command asks for it. Arguments naming groups of functions are walked
+// first, so '/cmd group', '/cmd group help' and '/cmd help group' all
+// render the group's help. Requests that can not be parsed are left to the
+// RegistryHandler to report.
+func (h *HelpHandler) Before(context *router.ContextMap, task *router.TaskMap) bool {
+	cmd, err := slashCommand(context, task)
+	if err != nil {
+		return false
+	}
+
+	registry := registrySnapshot(context, h.registry).(helpRegistryInterface)
+	help, err := registry.Help(cmd.Command)
+	if err != nil {
+		if len(cmd.Arguments) == 0 || isHelpKeyword(cmd.Arguments[0]) {
+			recordErr(task, err)
+			return true
+		}
+		return false
+	}
+
+	title := help.Command
+	node := router.FunctionHelp{Name: help.Command, Functions: help.Functions}
+	args := cmd.Arguments
+	for len(args) > 0 {
+		group, ok := node.Function(args[0])
+		if !ok || !group.IsGroup() {
+			break
+		}
+		title, node, args = title+" "+group.Name, group, args[1:]
+	}
+	if len(args) > 0 && definesFunction(node, args[0]) {
+		return false
+	}
+
+	switch {
+	case len(args) == 0:
+	case strings.EqualFold(args[0], listKeyword):
+		args = nil
+	case strings.EqualFold(args[0], helpKeyword):
+		args = args[1:]
+	default:
+		return false
+	}
+
+	isFunction := false
+	for len(args) > 0 && !isFunction {
+		funcHelp, ok := node.Function(args[0])
+		if !ok {
+			funcNames := helpFunctionNames(node.Functions)
+			recordErr(task, router.FunctionNotFoundError{
+				Command:     title,
+				Function:    args[0],
+				Functions:   funcNames,
+				Suggestions: router.SuggestFunctions(args[0], funcNames),
+			})
+			return true
+		}
+		title, node, args = title+" "+funcHelp.Name, funcHelp, args[1:]
+		isFunction = !funcHelp.IsGroup()
+	}
+
+	if isFunction {
+		h.respond(task, functionHelpText(title, node), functionHelpBlocks(title, node))
+	} else {
+		h.respond(task, groupHelpText(title, node.Functions), groupHelpBlocks(title, node.Functions))
+	}
+
+	return true
+}
+
+// Execute method that does nothing.
+func (h *HelpHandler) Execute(context *router.ContextMap, task *router.TaskMap) {}
+
+// After method that does nothing.
+func (h *HelpHandler) After(context *router.ContextMap, task *router.TaskMap) {}
+
+func (h *HelpHandler) respond(task *router.TaskMap, text string, blocks []slackBlock) {
+	task.SetStatusCode(http.StatusOK)
+	if h.format != HelpBlocks {
+		task.SetResponseHeader(contentTypeHeader, textContentType)
+		task.SetBody(text)
+		return
+	}
+
+	body, err := json.Marshal(slackMessage{
+		ResponseType: ephemeralRespType,
+		Text:         text,
+		Blocks:       blocks,
+	})
+	if err != nil {
+		recordErr(task, err)
+		return
+	}
+	task.SetResponseHeader(contentTypeHeader, jsonContentType)
+	task.SetBody(string(body))
+}
+
+// slackMessage is a message in the JSON form Slack accepts as the response
+// to a slash command.
+type slackMessage struct {
+	ResponseType string       `json:"response_type,omitempty"`
+	Text         string       `json:"text"`
+	Blocks       []slackBlock `json:"blocks,omitempty"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func headerBlock(text string) slackBlock {
+	return slackBlock{Type: "header", Text: &slackText{Type: "plain_text", Text: text}}
+}
+
+func sectionBlock(mrkdwn string) slackBlock {
+	return slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: mrkdwn}}
+}
+
+func contextBlock(mrkdwn string) slackBlock {
+	return slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: mrkdwn}}}
+}
+
+// slackEscaper escapes the characters Slack reserves for links and
+// mentions in formatted text.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func isHelpKeyword(arg string) bool {
+	return strings.EqualFold(arg, helpKeyword) || strings.EqualFold(arg, listKeyword)
+}
+
+// definesFunction reports whether the command or group defines a function
+// named after the built-in keyword.
+func definesFunction(node router.FunctionHelp, keyword string) bool {
+	if !isHelpKeyword(keyword) {
+		return false
+	}
+	for _, funcHelp := range node.Functions {
+		if strings.EqualFold(funcHelp.Name, keyword) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupHelpText lists the tree of functions under a command or group.
+func groupHelpText(title string, functions []router.FunctionHelp) string {
+	lines := []string{"*" + slackEscaper.Replace(title) + "*"}
+	if len(functions) == 0 {
+		return strings.Join(append(lines, helpNoFunctionsText), "\n")
+	}
+	for _, funcHelp := range functions {
+		lines = append(lines, functionTree(funcHelp, 0)...)
+	}
+	lines = append(lines, helpFooter(title))
+
+	return strings.Join(lines, "\n")
+}
+
+func groupHelpBlocks(title string, functions []router.FunctionHelp) []slackBlock {
+	blocks := []slackBlock{headerBlock(title)}
+	if len(functions) == 0 {
+		return append(blocks, sectionBlock(helpNoFunctionsText))
+	}
+	for _, funcHelp := range functions {
+		lines := functionTree(funcHelp, 0)
+		lines[0] = strings.TrimPrefix(lines[0], helpBullet)
+		blocks = append(blocks, sectionBlock(strings.Join(lines, "\n")))
+	}
+
+	return append(blocks, contextBlock(helpFooter(title)))
+}
+
+func functionHelpText(title string, funcHelp router.FunctionHelp) string {
+	return strings.Join(append([]string{"*" + slackEscaper.Replace(title) + "*"}, functionDetails(funcHelp)...), "\n")
+}
+
+func functionHelpBlocks(title string, funcHelp router.FunctionHelp) []slackBlock {
+	return []slackBlock{
+		headerBlock(title),
+		sectionBlock(strings.Join(functionDetails(funcHelp), "\n")),
+	}
+}
+
+// functionTree lists a function, or a group and the functions nested
+// under it, indented by depth.
+func functionTree(funcHelp router.FunctionHelp, depth int) []string {
+	indent := strings.Repeat(helpIndent, depth)
+	lines := strings.Split(functionSummary(funcHelp), "\n")
+	lines[0] = indent + helpBullet + lines[0]
+	for i := 1; i < len(lines); i++ {
+		lines[i] = indent + lines[i]
+	}
+	for _, child := range funcHelp.Functions {
+		lines = append(lines, functionTree(child, depth+1)...)
+	}
+
+	return lines
+}
+
+// functionSummary describes a function in the list of its command's or
+// group's functions.
+func functionSummary(funcHelp router.FunctionHelp) string {
+	summary := "*" + slackEscaper.Replace(funcHelp.Name) + "*"
+	if len(funcHelp.Aliases) > 0 {
+		summary += " (" + slackEscaper.Replace(strings.Join(funcHelp.Aliases, ", ")) + ")"
+	}
+	if funcHelp.Usage != "" {
+		summary += ": `" + slackEscaper.Replace(funcHelp.Usage) + "`"
+	}
+	if funcHelp.Description != "" {
+		summary += "\n" + slackEscaper.Replace(funcHelp.Description)
+	}
+
+	return summary
+}
+
+// functionDetails are the lines of a function's own help.
+func functionDetails(funcHelp router.FunctionHelp) []string {
+	details := []string{"`" + slackEscaper.Replace(funcHelp.Usage) + "`"}
+	if len(funcHelp.Aliases) > 0 {
+		details = append(details, fmt.Sprintf(helpAliasesFmt, slackEscaper.Replace(strings.Join(funcHelp.Aliases, ", "))))
+	}
+	if funcHelp.Description != "" {
+		details = append(details, slackEscaper.Replace(funcHelp.Description))
+	}
+	if funcHelp.Manual != "" {
+		details = append(details, fmt.Sprintf(helpManualFmt, slackEscaper.Replace(funcHelp.Manual)))
+	}
+
+	return details
+}
+
+func helpFooter(title string) string {
+	return slackEscaper.Replace(fmt.Sprintf(helpFooterFmt, title))
+}
+
+func helpFunctionNames(functions []router.FunctionHelp) []string {
+	names := make([]string, len(functions))
+	for i, funcHelp := range functions {
+		names[i] = funcHelp.Name
+	}
+
+	return names
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+const (
+	testCommandHelpText = "*/deploy*\n" +
+		"• *rollback*: `/deploy rollback &lt;service&gt;`\nRolls back a service.\n" +
+		"• *ship*: `/deploy ship &lt;service&gt;`\nShips a service.\n" +
+		"Type `/deploy help &lt;function&gt;` to learn more about a function."
+	testFunctionHelpText = "*/deploy ship*\n" +
+		"`/deploy ship &lt;service&gt;`\n" +
+		"Ships a service.\n" +
+		"Manual: https://wiki.example.com/deploy"
+)
+
+func newTestHelpHandler(t *testing.T, format HelpFormat) HelpHandler {
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(testRegJson))
+	assert.Nil(t, err)
+
+	return NewHelpHandlerWithFormat(cmdReg, format)
+}
+
+func TestHelpHandlerCommandHelp(t *testing.T) {
+	testHandler := newTestHelpHandler(t, HelpText)
+	for _, body := range []string{"command=%2Fdeploy&text=", "command=%2Fdeploy&text=HELP"} {
+		testCtx := make(router.ContextMap)
+		testTask := router.TaskMap{TaskBody: body}
+
+		assert.True(t, testHandler.Before(&testCtx, &testTask), body)
+		statusCode, _ := testTask.StatusCode()
+		assert.Equal(t, statusCode, http.StatusOK)
+		assert.Equal(t, testTask.Body(), testCommandHelpText)
+		assert.Equal(t, testTask.ResponseHeaders()[contentTypeHeader], textContentType)
+		assert.Nil(t, testTask.Err())
+	}
+}
+
+func TestHelpHandlerFunctionHelp(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fdeploy&text=help+Ship"}
+	testHandler := newTestHelpHandler(t, HelpText)
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testTask.Body(), testFunctionHelpText)
+}
+
+func TestHelpHandlerFunctionNotFound(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fdeploy&text=help+launch"}
+	testHandler := newTestHelpHandler(t, HelpText)
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	var testErr router.FunctionNotFoundError
+	assert.ErrorAs(t, testTask.Err(), &testErr)
+	assert.Equal(t, testErr.Functions, []string{"rollback", "ship"})
+}
+
+func TestHelpHandlerBlocks(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fdeploy&text=help"}
+	testHandler := newTestHelpHandler(t, HelpBlocks)
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testTask.ResponseHeaders()[contentTypeHeader], jsonContentType)
+
+	var msg slackMessage
+	assert.Nil(t, json.Unmarshal([]byte(testTask.Body()), &msg))
+	assert.Equal(t, msg.ResponseType, ephemeralRespType)
+	assert.Equal(t, msg.Text, testCommandHelpText)
+	assert.Len(t, msg.Blocks, 4)
+	assert.Equal(t, msg.Blocks[0], headerBlock("/deploy"))
+	assert.Equal(t, msg.Blocks[1], sectionBlock("*rollback*: `/deploy rollback &lt;service&gt;`\nRolls back a service."))
+	assert.Equal(t, msg.Blocks[3].Type, "context")
+}
+
+func TestHelpHandlerPassesThrough(t *testing.T) {
+	testHandler := newTestHelpHandler(t, HelpText)
+	for _, body := range []string{"command=%2Fdeploy&text=ship+api", "text=help"} {
+		testCtx := make(router.ContextMap)
+		testTask := router.TaskMap{TaskBody: body}
+
+		assert.False(t, testHandler.Before(&testCtx, &testTask), body)
+		assert.Nil(t, testTask.Err())
+		_, hasStatusCode := testTask.StatusCode()
+		assert.False(t, hasStatusCode)
+	}
+}
+
+func TestHelpHandlerList(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fdeploy&text=list+ship"}
+	testHandler := newTestHelpHandler(t, HelpText)
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testTask.Body(), testCommandHelpText)
+}
+
+func TestHelpHandlerAliases(t *testing.T) {
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(`{"/ops": {"functions": {"ship": {"usage": "/ops ship", "aliases": ["release"]}}}}`))
+	assert.Nil(t, err)
+	testHandler := NewHelpHandler(cmdReg)
+
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fops&text=help"}
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Contains(t, testTask.Body(), "• *ship* (release): `/ops ship`")
+
+	testCtx = make(router.ContextMap)
+	testTask = router.TaskMap{TaskBody: "command=%2Fops&text=help+RELEASE"}
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testTask.Body(), "*/ops ship*\n`/ops ship`\nAlso known as: release")
+}
+
+func TestHelpHandlerDefinedHelpFunction(t *testing.T) {
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(`{
+           "/ops": {
+               "functions": {
+                   "help": {"usage": "/ops help <topic>"},
+                   "db": {"functions": {"list": {"usage": "/ops db list"}}}
+               }
+           }
+        }`))
+	assert.Nil(t, err)
+	testHandler := NewHelpHandler(cmdReg)
+
+	for _, text := range []string{"help", "HELP+db", "db+list"} {
+		testCtx := make(router.ContextMap)
+		testTask := router.TaskMap{TaskBody: "command=%2Fops&text=" + text}
+		assert.False(t, testHandler.Before(&testCtx, &testTask), text)
+		_, hasStatusCode := testTask.StatusCode()
+		assert.False(t, hasStatusCode, text)
+	}
+
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fops&text=list"}
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Contains(t, testTask.Body(), "• *help*: `/ops help &lt;topic&gt;`")
+
+	testCtx = make(router.ContextMap)
+	testTask = router.TaskMap{TaskBody: "command=%2Fops&text=db+help"}
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Contains(t, testTask.Body(), "*/ops db*")
+}
+
+func TestHelpHandlerCommandNotFound(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fbuild&text=help"}
+	testHandler := newTestHelpHandler(t, HelpText)
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, ErrorKindOf(testTask.Err()), KindNotFound)
+}
+
+func TestHelpHandlerNestedTree(t *testing.T) {
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(testNestedRegJson))
+	assert.Nil(t, err)
+	testHandler := NewHelpHandler(cmdReg)
+
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fops&text=help"}
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testTask.Body(), "*/ops*\n"+
+		"• *db*\n"+
+		"Database operations.\n"+
+		"    • *backup*: `/ops db backup &lt;database&gt;`\n"+
+		"    Backs up a database.\n"+
+		"    • *replica*\n"+
+		"        • *promote*: `/ops db replica promote &lt;replica&gt;`\n"+
+		"• *status*: `/ops status`\n"+
+		"Type `/ops help &lt;function&gt;` to learn more about a function.")
+}
+
+func TestHelpHandlerNestedGroup(t *testing.T) {
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(testNestedRegJson))
+	assert.Nil(t, err)
+	testHandler := NewHelpHandler(cmdReg)
+	testGroupHelp := "*/ops db replica*\n" +
+		"• *promote*: `/ops db replica promote &lt;replica&gt;`\n" +
+		"Type `/ops db replica help &lt;function&gt;` to learn more about a function."
+
+	for _, text := range []string{"db+replica", "db+replica+help", "help+db+replica", "db+help+replica", "DB+replica+list"} {
+		testCtx := make(router.ContextMap)
+		testTask := router.TaskMap{TaskBody: "command=%2Fops&text=" + text}
+		assert.True(t, testHandler.Before(&testCtx, &testTask), text)
+		assert.Equal(t, testTask.Body(), testGroupHelp, text)
+	}
+
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fops&text=db+help+backup"}
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testTask.Body(), "*/ops db backup*\n`/ops db backup &lt;database&gt;`\nBacks up a database.")
+
+	testCtx = make(router.ContextMap)
+	testTask = router.TaskMap{TaskBody: "command=%2Fops&text=help+db+restore"}
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	var testErr router.FunctionNotFoundError
+	assert.ErrorAs(t, testTask.Err(), &testErr)
+	assert.Equal(t, testErr.Command, "/ops db")
+
+	testCtx = make(router.ContextMap)
+	testTask = router.TaskMap{TaskBody: "command=%2Fops&text=db+backup+orders"}
+	assert.False(t, testHandler.Before(&testCtx, &testTask))
+}
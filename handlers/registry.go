@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"github.com/phoenixcoder/slack-golang-sdk/slashcmd"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	formContentType  = "application/x-www-form-urlencoded"
+	noSlashCmdErrMsg = "Slash command was not provided in the request body."
+)
+
+type registryInterface interface {
+	GetFunctionRecord(cmd *slashcmd.Info) (*router.FunctionRecord, error)
+}
+
+type snapshotRegistryInterface interface {
+	Snapshot() router.RegistrySnapshot
+}
+
+// RegistryHandler resolves the slash command in the request body to a
+// function in the command registry, and points the proxy at the endpoint
+// that serves it.
+type RegistryHandler struct {
+	baseUrl  string
+	registry registryInterface
+}
+
+// NewRegistryHandler is a factory method for creating the registry handler
+// with a loaded command registry. Function endpoints are resolved as
+// '<baseUrl>/<command>/<function>', or '<baseUrl>/<command>/<group>/<function>'
+// for nested functions, unless the function record names its own url.
+func NewRegistryHandler(registry registryInterface, baseUrl string) RegistryHandler {
+	return RegistryHandler{
+		baseUrl:  strings.TrimRight(baseUrl, "/"),
+		registry: registry,
+	}
+}
+
+// Before method that parses the slash command, looks up its function in
+// the registry and writes the function's url, method, headers, forwarded
+// headers, query, body transform, timeout, content type and whether it is
+// asynchronous into the context, along with the arguments that followed
+// the function's name. The request is stopped if the command or function
+// can not be found, with the error recorded on the task and its response
+// rendered in place of the slash command.
+func (r *RegistryHandler) Before(context *router.ContextMap, task *router.TaskMap) bool {
+	cmd, err := slashCommand(context, task)
+	if err != nil {
+		recordErr(task, NewError(KindBadRequest, "", err))
+		return true
+	}
+
+	registry := registrySnapshot(context, r.registry).(registryInterface)
+	funcRec, err := registry.GetFunctionRecord(cmd)
+	if err != nil {
+		recordErr(task, err)
+		return true
+	}
+
+	(*context)[requestUrl] = r.functionUrl(cmd, funcRec)
+	(*context)[FunctionArgsKey] = funcRec.Args
+	if funcRec.Method != "" {
+		(*context)[requestMethod] = strings.ToUpper(funcRec.Method)
+	}
+	if len(funcRec.Headers) > 0 {
+		(*context)[requestHeaders] = funcRec.Headers
+	}
+	if len(funcRec.ForwardHeaders) > 0 {
+		(*context)[requestForwardHeaders] = funcRec.ForwardHeaders
+	}
+	if len(funcRec.Query) > 0 {
+		(*context)[requestQuery] = funcRec.Query
+	}
+	if funcRec.BodyTransform != "" {
+		(*context)[requestBodyTransform] = funcRec.BodyTransform
+	}
+	if funcRec.Timeout > 0 {
+		(*context)[requestTimeout] = time.Duration(funcRec.Timeout)
+	}
+	if funcRec.Async {
+		(*context)[requestAsync] = true
+		(*context)[requestAck] = funcRec.Acknowledgement
+	}
+	if funcRec.ContentType != "" {
+		(*context)[contentTypeHeader] = funcRec.ContentType
+	} else if funcRec.BodyTransform == router.BodyJSON {
+		(*context)[contentTypeHeader] = jsonContentType
+	} else if _, ok := (*context)[contentTypeHeader]; !ok {
+		(*context)[contentTypeHeader] = formContentType
+	}
+
+	return false
+}
+
+// Execute method that does nothing.
+func (r *RegistryHandler) Execute(context *router.ContextMap, task *router.TaskMap) {}
+
+// After method that does nothing.
+func (r *RegistryHandler) After(context *router.ContextMap, task *router.TaskMap) {}
+
+func (r *RegistryHandler) functionUrl(cmd *slashcmd.Info, funcRec *router.FunctionRecord) string {
+	if funcRec.Url != "" {
+		return funcRec.Url
+	}
+
+	cmdName := strings.TrimPrefix(strings.ToLower(cmd.Command), "/")
+	funcPath := funcRec.Path
+	if len(funcPath) == 0 {
+		funcPath = []string{strings.ToLower(cmd.Arguments[0])}
+	}
+
+	endpoint := r.baseUrl + "/" + url.PathEscape(cmdName)
+	for _, name := range funcPath {
+		endpoint += "/" + url.PathEscape(name)
+	}
+
+	return endpoint
+}
+
+// slashCommand returns the command and arguments of the slash command in
+// the task's body. The first handler to ask parses it with the SDK, and
+// the result is kept in the context for the handlers that follow.
+func slashCommand(context *router.ContextMap, task *router.TaskMap) (*slashcmd.Info, error) {
+	if cmd, ok := (*context)[SlashCommandKey].(*slashcmd.Info); ok {
+		return cmd, nil
+	}
+
+	cmd, err := slashcmd.Parse(task.Body())
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Command == "" {
+		return nil, errors.New(noSlashCmdErrMsg)
+	}
+	(*context)[SlashCommandKey] = cmd
+
+	return cmd, nil
+}
+
+// registrySnapshot returns the snapshot of the command registry kept in
+// the context. The first handler to ask takes it from its registry, so
+// the handlers that follow see the same registry even if it is reloaded
+// during the request. Registries that can not be snapshotted are returned
+// as they are.
+func registrySnapshot(context *router.ContextMap, registry interface{}) interface{} {
+	if snapshot, ok := (*context)[RegistrySnapshotKey].(router.RegistrySnapshot); ok {
+		return snapshot
+	}
+
+	snapshotRegistry, ok := registry.(snapshotRegistryInterface)
+	if !ok {
+		return registry
+	}
+	snapshot := snapshotRegistry.Snapshot()
+	(*context)[RegistrySnapshotKey] = snapshot
+
+	return snapshot
+}
+
+// slashCommandForm returns the form encoded fields Slack sent with the
+// slash command, such as its user_id and response_url. Like the command,
+// they are parsed once and kept in the context.
+func slashCommandForm(context *router.ContextMap, task *router.TaskMap) (url.Values, error) {
+	if form, ok := (*context)[SlashCommandFormKey].(url.Values); ok {
+		return form, nil
+	}
+
+	form, err := url.ParseQuery(task.Body())
+	if err != nil {
+		return nil, err
+	}
+	(*context)[SlashCommandFormKey] = form
+
+	return form, nil
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const (
+	testRegBaseUrl = "https://functions.example.com/"
+	testRegJson    = `{
+                           "/deploy": {
+                               "reservedKeywords" : ["help"],
+                               "functions" : {
+                                   "Ship" : {
+                                       "usage" : "/deploy ship <service>",
+                                       "description" : "Ships a service.",
+                                       "manual" : "https://wiki.example.com/deploy"
+                                   },
+                                   "rollback" : {
+                                       "usage" : "/deploy rollback <service>",
+                                       "description" : "Rolls back a service.",
+                                       "manual" : "https://wiki.example.com/deploy",
+                                       "url" : "https://rollback.example.com/run",
+                                       "method" : "put",
+                                       "headers" : {"X-Api-Key" : "secret"},
+                                       "timeout" : "2s",
+                                       "contentType" : "application/json"
+                                   }
+                               }
+                           }
+                       }`
+)
+
+const testNestedRegJson = `{
+           "/ops": {
+               "functions" : {
+                   "db" : {
+                       "description" : "Database operations.",
+                       "functions" : {
+                           "backup" : {
+                               "usage" : "/ops db backup <database>",
+                               "description" : "Backs up a database."
+                           },
+                           "replica" : {
+                               "functions" : {
+                                   "promote" : {
+                                       "usage" : "/ops db replica promote <replica>"
+                                   }
+                               }
+                           }
+                       }
+                   },
+                   "status" : {
+                       "usage" : "/ops status"
+                   }
+               }
+           }
+        }`
+
+func newTestRegistryHandler(t *testing.T) RegistryHandler {
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(testRegJson))
+	assert.Nil(t, err)
+
+	return NewRegistryHandler(cmdReg, testRegBaseUrl)
+}
+
+func TestRegistryHandler(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{
+		TaskBody: "command=%2Fdeploy&text=SHIP+api&user_id=U2147483697",
+	}
+	testHandler := newTestRegistryHandler(t)
+
+	assert.False(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testCtx[requestUrl], "https://functions.example.com/deploy/ship")
+	assert.Equal(t, testCtx[contentTypeHeader], formContentType)
+	assert.NotNil(t, testCtx[SlashCommandKey])
+	_, hasStatusCode := testTask.StatusCode()
+	assert.False(t, hasStatusCode)
+}
+
+func TestRegistryHandlerFunctionEndpoint(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{
+		TaskBody: "command=%2Fdeploy&text=rollback+api",
+	}
+	testHandler := newTestRegistryHandler(t)
+
+	assert.False(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testCtx[requestUrl], "https://rollback.example.com/run")
+	assert.Equal(t, testCtx[requestMethod], http.MethodPut)
+	assert.Equal(t, testCtx[requestHeaders], map[string]string{"X-Api-Key": "secret"})
+	assert.Equal(t, testCtx[requestTimeout], 2*time.Second)
+	assert.Equal(t, testCtx[contentTypeHeader], "application/json")
+}
+
+func TestRegistryHandlerFunctionNotFound(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{
+		TaskBody: "command=%2Fdeploy&text=launch+api",
+	}
+	testHandler := newTestRegistryHandler(t)
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Nil(t, testCtx[requestUrl])
+
+	var testErr router.FunctionNotFoundError
+	assert.ErrorAs(t, testTask.Err(), &testErr)
+	assert.Equal(t, testErr.Function, "launch")
+	assert.Equal(t, ErrorKindOf(testTask.Err()), KindUsage)
+	assert.Equal(t, errRespMsg(testTask.Err()), "We're embarrassed for you, but we don't know a 'launch'. Try these instead:\n'rollback', 'ship'")
+
+	statusCode, _ := testTask.StatusCode()
+	assert.Equal(t, statusCode, http.StatusOK)
+	assert.Equal(t, testTask.ResponseHeaders()[contentTypeHeader], jsonContentType)
+	assert.JSONEq(t, testTask.Body(), `{"response_type": "ephemeral", "text": "We're embarrassed for you, but we don't know a 'launch'. Try these instead:\n'rollback', 'ship'"}`)
+}
+
+func TestRegistryHandlerArgsNotFound(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{
+		TaskBody: "command=%2Fdeploy&text=",
+	}
+	testHandler := newTestRegistryHandler(t)
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Nil(t, testCtx[requestUrl])
+
+	var testErr router.ArgsNotFoundError
+	assert.ErrorAs(t, testTask.Err(), &testErr)
+	assert.Equal(t, ErrorKindOf(testTask.Err()), KindUsage)
+	assert.Equal(t, errRespMsg(testTask.Err()), "You'll have to tell us what to do. Try one of these:\n'rollback', 'ship'")
+}
+
+func TestRegistryHandlerCommandNotFound(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{
+		TaskBody: "command=%2Fbuild&text=ship",
+	}
+	testHandler := newTestRegistryHandler(t)
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Nil(t, testCtx[requestUrl])
+	assert.Equal(t, ErrorKindOf(testTask.Err()), KindNotFound)
+	assert.Equal(t, testTask.Body(), cmdNotFoundErrRespMsg)
+}
+
+func TestRegistryHandlerNoCommand(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{
+		TaskBody: "text=ship",
+	}
+	testHandler := newTestRegistryHandler(t)
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Nil(t, testCtx[SlashCommandKey])
+	assert.Equal(t, ErrorKindOf(testTask.Err()), KindBadRequest)
+}
+
+func TestRegistryHandlerFunctionSuggestion(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{
+		TaskBody: "command=%2Fdeploy&text=rolback+api",
+	}
+	testHandler := newTestRegistryHandler(t)
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, errRespMsg(testTask.Err()), "We're embarrassed for you, but we don't know a 'rolback'. Did you mean 'rollback'?")
+}
+
+func TestRegistryHandlerAlias(t *testing.T) {
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(`{"/deploy": {"functions": {"ship": {"usage": "/deploy ship", "aliases": ["Release"]}}}}`))
+	assert.Nil(t, err)
+	testHandler := NewRegistryHandler(cmdReg, testRegBaseUrl)
+
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{
+		TaskBody: "command=%2Fdeploy&text=release+api",
+	}
+
+	assert.False(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testCtx[requestUrl], "https://functions.example.com/deploy/ship")
+}
+
+func TestRegistryHandlerReservedKeyword(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{
+		TaskBody: "command=%2Fdeploy&text=help",
+	}
+	testHandler := newTestRegistryHandler(t)
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, ErrorKindOf(testTask.Err()), KindUsage)
+	assert.Equal(t, errRespMsg(testTask.Err()), "'help' is reserved. Try '/deploy help' instead.")
+}
+
+func TestRegistryHandlerNestedFunction(t *testing.T) {
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(testNestedRegJson))
+	assert.Nil(t, err)
+	testHandler := NewRegistryHandler(cmdReg, testRegBaseUrl)
+
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{
+		TaskBody: "command=%2Fops&text=db+backup+orders+--full",
+	}
+
+	assert.False(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testCtx[requestUrl], "https://functions.example.com/ops/db/backup")
+	assert.Equal(t, testCtx[FunctionArgsKey], []string{"orders", "--full"})
+}
+
+func TestRegistryHandlerAsyncFunction(t *testing.T) {
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(`{"/ops": {"functions": {"backup": {"usage": "/ops backup", "async": true, "acknowledgement": "Backing up..."}}}}`))
+	assert.Nil(t, err)
+	testHandler := NewRegistryHandler(cmdReg, testRegBaseUrl)
+
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fops&text=backup"}
+	assert.False(t, testHandler.Before(&testCtx, &testTask))
+	assert.Equal(t, testCtx[requestAsync], true)
+	assert.Equal(t, testCtx[requestAck], "Backing up...")
+
+	testCtx = make(router.ContextMap)
+	testTask = router.TaskMap{TaskBody: "command=%2Fdeploy&text=ship+api"}
+	testHandler = newTestRegistryHandler(t)
+	assert.False(t, testHandler.Before(&testCtx, &testTask))
+	_, hasAsync := testCtx[requestAsync]
+	assert.False(t, hasAsync)
+}
+
+func TestSlashCommandParsedOnce(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fdeploy&text=ship+api&user_id=U2147483697"}
+
+	cmd, err := slashCommand(&testCtx, &testTask)
+	assert.Nil(t, err)
+	form, err := slashCommandForm(&testCtx, &testTask)
+	assert.Nil(t, err)
+
+	testTask.SetBody("command=%2Fbuild&text=")
+	cachedCmd, err := slashCommand(&testCtx, &testTask)
+	assert.Nil(t, err)
+	assert.Equal(t, cachedCmd, cmd)
+	assert.Equal(t, cachedCmd.Arguments, []string{"ship", "api"})
+	cachedForm, err := slashCommandForm(&testCtx, &testTask)
+	assert.Nil(t, err)
+	assert.Equal(t, cachedForm.Get("user_id"), form.Get("user_id"))
+}
+
+func TestRegistrySnapshotShared(t *testing.T) {
+	nestedReg, err := router.NewCommandRegistryFromContents([]byte(testNestedRegJson))
+	assert.Nil(t, err)
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fdeploy&text=ship+api"}
+
+	// The registry snapshotted by an earlier handler is used, rather than
+	// the handler's own.
+	assert.Equal(t, registrySnapshot(&testCtx, nestedReg), nestedReg)
+	testHandler := newTestRegistryHandler(t)
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	assert.Nil(t, testCtx[requestUrl])
+	assert.Equal(t, ErrorKindOf(testTask.Err()), KindNotFound)
+}
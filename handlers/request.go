@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	requestForwardHeaders = "request-forward-headers"
+	requestQuery          = "request-query"
+	requestBodyTransform  = "request-body-transform"
+
+	slashCmdFunctionArgsField = "function_arguments"
+
+	templateArgsPrefix = "args."
+	templateEnvPrefix  = "env."
+
+	errEnvNotAllowedFmt = "Environment variable is not allowed in templates. Name: '%s'"
+)
+
+var templatePattern = regexp.MustCompile(`\{([A-Za-z0-9_.\-]+)\}`)
+
+// proxyRequest is the request the ProxyHandler sends to a function.
+type proxyRequest struct {
+	method string
+	url    string
+	header http.Header
+	body   string
+}
+
+// newProxyRequest shapes the request sent to the function from the
+// context and the incoming task: the method, the url with its templated
+// query, the forwarded and injected headers, and the transformed body.
+// Injected headers replace forwarded ones of the same name. The arguments
+// left after the function's name are sent as a repeated
+// 'function_arguments' field, in the body or, for requests without one,
+// in the query. Templates may only name the environment variables in
+// templateEnv.
+func newProxyRequest(ctxMap *router.ContextMap, task *router.TaskMap, requestUrl string, contentType string, templateEnv []string) (*proxyRequest, error) {
+	form, _ := slashCommandForm(ctxMap, task)
+	args, _ := (*ctxMap)[ArgumentsKey].(router.Arguments)
+	funcArgs := ctxMap.Strings(FunctionArgsKey)
+	if len(funcArgs) > 0 {
+		form = withFunctionArguments(form, funcArgs)
+	}
+
+	method, _ := ctxMap.String(requestMethod)
+	if method == "" {
+		method = http.MethodPost
+	}
+	hasBody := method != http.MethodGet && method != http.MethodHead
+
+	query := ctxMap.StringMap(requestQuery)
+	if len(query) > 0 || (!hasBody && len(funcArgs) > 0) {
+		parsedUrl, err := url.Parse(requestUrl)
+		if err != nil {
+			return nil, err
+		}
+		values := parsedUrl.Query()
+		for key, val := range query {
+			expanded, err := expandTemplate(val, form, args, templateEnv)
+			if err != nil {
+				return nil, err
+			}
+			values.Set(key, expanded)
+		}
+		if !hasBody && len(funcArgs) > 0 {
+			values[slashCmdFunctionArgsField] = funcArgs
+		}
+		parsedUrl.RawQuery = values.Encode()
+		requestUrl = parsedUrl.String()
+	}
+
+	header := make(http.Header)
+	for _, name := range ctxMap.Strings(requestForwardHeaders) {
+		if val := task.Header(name); val != "" {
+			header.Set(name, val)
+		}
+	}
+	for key, val := range ctxMap.StringMap(requestHeaders) {
+		expanded, err := expandTemplate(val, form, args, templateEnv)
+		if err != nil {
+			return nil, err
+		}
+		header.Set(key, expanded)
+	}
+
+	var body string
+	if hasBody {
+		body = task.Body()
+		if len(funcArgs) > 0 {
+			body = form.Encode()
+		}
+		transform, _ := ctxMap.String(requestBodyTransform)
+		transformed, err := transformBody(transform, body, form)
+		if err != nil {
+			return nil, err
+		}
+		body = transformed
+		if contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+	}
+
+	return &proxyRequest{
+		method: method,
+		url:    requestUrl,
+		header: header,
+		body:   body,
+	}, nil
+}
+
+// transformBody shapes the slash command payload for the function. The
+// JSON transform writes each form field as a string, or as an array of
+// strings if it is repeated, the arguments written by the ArgumentsHandler
+// as an object, and the function's arguments always as an array.
+func transformBody(transform string, body string, form url.Values) (string, error) {
+	if transform != router.BodyJSON {
+		return body, nil
+	}
+
+	fields := make(map[string]interface{}, len(form))
+	for key, vals := range form {
+		switch {
+		case key == slashCmdArgumentsField && len(vals) == 1 && json.Valid([]byte(vals[0])):
+			fields[key] = json.RawMessage(vals[0])
+		case key == slashCmdFunctionArgsField:
+			fields[key] = vals
+		case len(vals) == 1:
+			fields[key] = vals[0]
+		default:
+			fields[key] = vals
+		}
+	}
+
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
+
+// withFunctionArguments returns a copy of the slash command's form with the
+// function's arguments added, leaving the form kept in the context alone.
+func withFunctionArguments(form url.Values, funcArgs []string) url.Values {
+	withArgs := make(url.Values, len(form)+1)
+	for key, vals := range form {
+		withArgs[key] = vals
+	}
+	withArgs[slashCmdFunctionArgsField] = funcArgs
+
+	return withArgs
+}
+
+// expandTemplate replaces the {name} placeholders in the template with the
+// slash command's form field of that name, {args.name} with an argument
+// parsed by the ArgumentsHandler, and {env.NAME} with an environment
+// variable. Unknown names are replaced with nothing. Environment variables
+// not in templateEnv are refused, so that a registry can not send the
+// router's secrets to the functions it lists.
+func expandTemplate(tmpl string, form url.Values, args router.Arguments, templateEnv []string) (string, error) {
+	var err error
+	expanded := templatePattern.ReplaceAllStringFunc(tmpl, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		switch {
+		case strings.HasPrefix(name, templateEnvPrefix):
+			envName := strings.TrimPrefix(name, templateEnvPrefix)
+			for _, allowed := range templateEnv {
+				if envName == allowed {
+					return os.Getenv(envName)
+				}
+			}
+			if err == nil {
+				err = fmt.Errorf(errEnvNotAllowedFmt, envName)
+			}
+			return ""
+		case strings.HasPrefix(name, templateArgsPrefix):
+			return formatArgument(args[strings.TrimPrefix(name, templateArgsPrefix)])
+		}
+
+		return form.Get(name)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return expanded, nil
+}
+
+func formatArgument(val interface{}) string {
+	switch typedVal := val.(type) {
+	case nil:
+		return ""
+	case router.Mention:
+		return typedVal.ID
+	case time.Duration:
+		return typedVal.String()
+	}
+
+	return fmt.Sprint(val)
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSlashCmdBody = "command=%2Fdeploy&text=ship+api&user_id=U1&channel_id=C1&arguments=%7B%22service%22%3A%22api%22%7D"
+
+func TestNewProxyRequest(t *testing.T) {
+	os.Setenv("TEST_DEPLOY_TOKEN", "secret")
+	defer os.Unsetenv("TEST_DEPLOY_TOKEN")
+
+	testCtx := router.ContextMap{
+		requestMethod:         http.MethodPut,
+		requestHeaders:        map[string]string{"Authorization": "Bearer {env.TEST_DEPLOY_TOKEN}", "X-Trace": "router"},
+		requestForwardHeaders: []string{"X-Trace", "X-Request-Id", "X-Missing"},
+		requestQuery:          map[string]string{"user": "{user_id}", "service": "{args.service}", "wait": "{args.wait}"},
+		ArgumentsKey:          router.Arguments{"service": "api", "wait": 90 * time.Second},
+	}
+	testTask := router.TaskMap{
+		TaskBody:    testSlashCmdBody,
+		TaskHeaders: map[string]string{"x-trace": "caller", "x-request-id": "abc123", "Authorization": "Basic caller"},
+	}
+
+	preq, err := newProxyRequest(&testCtx, &testTask, "https://functions.example.com/deploy/ship?region=eu", formContentType, []string{"TEST_DEPLOY_TOKEN"})
+	assert.Nil(t, err)
+	assert.Equal(t, preq.method, http.MethodPut)
+	assert.Equal(t, preq.url, "https://functions.example.com/deploy/ship?region=eu&service=api&user=U1&wait=1m30s")
+	assert.Equal(t, preq.header, http.Header{
+		"Authorization": []string{"Bearer secret"},
+		"X-Trace":       []string{"router"},
+		"X-Request-Id":  []string{"abc123"},
+		"Content-Type":  []string{formContentType},
+	})
+	assert.Equal(t, preq.body, testSlashCmdBody)
+}
+
+func TestNewProxyRequestWithoutBody(t *testing.T) {
+	testCtx := router.ContextMap{requestMethod: http.MethodGet, requestBodyTransform: router.BodyJSON}
+	testTask := router.TaskMap{TaskBody: testSlashCmdBody}
+
+	preq, err := newProxyRequest(&testCtx, &testTask, testProxyUrl, jsonContentType, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, preq.method, http.MethodGet)
+	assert.Empty(t, preq.body)
+	assert.Empty(t, preq.header)
+}
+
+func TestNewProxyRequestJSONBody(t *testing.T) {
+	testCtx := router.ContextMap{requestBodyTransform: router.BodyJSON}
+	testTask := router.TaskMap{TaskBody: testSlashCmdBody + "&tag=a&tag=b"}
+
+	preq, err := newProxyRequest(&testCtx, &testTask, testProxyUrl, jsonContentType, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, preq.method, http.MethodPost)
+	assert.Equal(t, preq.header.Get("Content-Type"), jsonContentType)
+	assert.JSONEq(t, preq.body, `{
+		"command": "/deploy",
+		"text": "ship api",
+		"user_id": "U1",
+		"channel_id": "C1",
+		"arguments": {"service": "api"},
+		"tag": ["a", "b"]
+	}`)
+}
+
+func TestExpandTemplate(t *testing.T) {
+	form, _ := url.ParseQuery(testSlashCmdBody)
+	args := router.Arguments{
+		"count":  3,
+		"notify": router.Mention{ID: "U024BE7LH", Name: "bob"},
+	}
+
+	testCases := []struct {
+		tmpl     string
+		expanded string
+	}{
+		{"{command} {text}", "/deploy ship api"},
+		{"{args.count}x {args.notify}", "3x U024BE7LH"},
+		{"[{unknown}{args.unknown}{env.TEST_UNSET_VAR}]", "[]"},
+		{"{not a template}", "{not a template}"},
+	}
+	for _, testCase := range testCases {
+		expanded, err := expandTemplate(testCase.tmpl, form, args, []string{"TEST_UNSET_VAR"})
+		assert.Nil(t, err, testCase.tmpl)
+		assert.Equal(t, expanded, testCase.expanded)
+	}
+}
+
+func TestExpandTemplateRefusesEnv(t *testing.T) {
+	os.Setenv("TEST_SIGNING_SECRET", "secret")
+	defer os.Unsetenv("TEST_SIGNING_SECRET")
+	form, _ := url.ParseQuery(testSlashCmdBody)
+
+	expanded, err := expandTemplate("Bearer {env.TEST_SIGNING_SECRET}", form, nil, []string{"TEST_DEPLOY_TOKEN"})
+	assert.Empty(t, expanded)
+	assert.EqualError(t, err, fmt.Sprintf(errEnvNotAllowedFmt, "TEST_SIGNING_SECRET"))
+
+	testCtx := router.ContextMap{
+		requestUrl:     testProxyUrl,
+		requestHeaders: map[string]string{"Authorization": "Bearer {env.TEST_SIGNING_SECRET}"},
+	}
+	testTask := router.TaskMap{TaskBody: testSlashCmdBody}
+	mHttpClient := new(mockHttpClient)
+	testHandler := NewProxyHandler(mHttpClient)
+
+	testHandler.Execute(&testCtx, &testTask)
+	assert.EqualError(t, testTask.Err(), fmt.Sprintf(errEnvNotAllowedFmt, "TEST_SIGNING_SECRET"))
+	mHttpClient.AssertNotCalled(t, "Do", mock.Anything)
+}
+
+func TestProxyHandlerShapedRequest(t *testing.T) {
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(`{
+           "/deploy": {
+               "functions" : {
+                   "status" : {
+                       "usage" : "/deploy status <service>",
+                       "method" : "get",
+                       "query" : {"service" : "{text}"},
+                       "forwardHeaders" : ["X-Request-Id"]
+                   },
+                   "ship" : {
+                       "usage" : "/deploy ship <service>",
+                       "bodyTransform" : "json"
+                   }
+               }
+           }
+        }`))
+	assert.Nil(t, err)
+	regHandler := NewRegistryHandler(cmdReg, testRegBaseUrl)
+	mHttpClient := new(mockHttpClient)
+	testHandler := NewProxyHandler(mHttpClient)
+
+	isStatusRequest := func(req *http.Request) bool {
+		return req.Method == http.MethodGet &&
+			req.URL.String() == "https://functions.example.com/deploy/status?function_arguments=api&service=status+api" &&
+			req.Header.Get("X-Request-Id") == "abc123" &&
+			req.Body == nil
+	}
+	isShipRequest := func(req *http.Request) bool {
+		body, _ := ioutil.ReadAll(req.Body)
+		return req.Method == http.MethodPost &&
+			req.Header.Get("Content-Type") == jsonContentType &&
+			string(body) == `{"command":"/deploy","function_arguments":["api"],"text":"ship api"}`
+	}
+	for _, isExpectedRequest := range []func(*http.Request) bool{isStatusRequest, isShipRequest} {
+		mHttpClient.On("Do", mock.MatchedBy(isExpectedRequest)).Return(&http.Response{
+			StatusCode: http.StatusOK,
+			Body:       ioutil.NopCloser(strings.NewReader(testProxyBody)),
+		}, nil).Once()
+	}
+
+	for _, body := range []string{"command=%2Fdeploy&text=status+api", "command=%2Fdeploy&text=ship+api"} {
+		testCtx := make(router.ContextMap)
+		testTask := router.TaskMap{TaskBody: body, TaskHeaders: map[string]string{"X-Request-Id": "abc123"}}
+
+		assert.False(t, regHandler.Before(&testCtx, &testTask))
+		testHandler.Execute(&testCtx, &testTask)
+		assert.Nil(t, testTask.Err(), body)
+		assert.Equal(t, testTask.Body(), testProxyBody)
+	}
+	mHttpClient.AssertNumberOfCalls(t, "Do", 2)
+}
+
+func TestProxyHandlerForwardsFunctionArguments(t *testing.T) {
+	var received url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Nil(t, r.ParseForm())
+		received = r.PostForm
+		w.Write([]byte(testProxyBody))
+	}))
+	defer server.Close()
+
+	cmdReg, err := router.NewCommandRegistryFromContents([]byte(testNestedRegJson))
+	assert.Nil(t, err)
+	regHandler := NewRegistryHandler(cmdReg, server.URL+"/")
+	testHandler := NewProxyHandler(server.Client())
+
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{TaskBody: "command=%2Fops&text=db+backup+orders+--full"}
+	assert.False(t, regHandler.Before(&testCtx, &testTask))
+	testHandler.Execute(&testCtx, &testTask)
+
+	assert.Nil(t, testTask.Err())
+	assert.Equal(t, received[slashCmdFunctionArgsField], []string{"orders", "--full"})
+	assert.Equal(t, received.Get("text"), "db backup orders --full")
+	form, _ := slashCommandForm(&testCtx, &testTask)
+	assert.Empty(t, form[slashCmdFunctionArgsField])
+}
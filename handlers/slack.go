@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"strconv"
+	"time"
+)
+
+const (
+	slackSignatureHeader = "X-Slack-Signature"
+	slackTimestampHeader = "X-Slack-Request-Timestamp"
+	slackSignatureVer    = "v0"
+
+	// DefaultSlackTimestampTolerance is the maximum age of a Slack request
+	// timestamp before the request is considered a replay.
+	DefaultSlackTimestampTolerance = 5 * time.Minute
+)
+
+// SlackSignatureHandler verifies that a request was sent by Slack using
+// the app's signing secret. Requests that fail verification are stopped
+// with a forbidden response.
+type SlackSignatureHandler struct {
+	signingSecret string
+	tolerance     time.Duration
+	now           func() time.Time
+}
+
+// NewSlackSignatureHandler is a factory method for creating the signature
+// handler with the app's signing secret and the default timestamp tolerance.
+func NewSlackSignatureHandler(signingSecret string) SlackSignatureHandler {
+	return NewSlackSignatureHandlerWithTolerance(signingSecret, DefaultSlackTimestampTolerance)
+}
+
+// NewSlackSignatureHandlerWithTolerance is a factory method for creating the
+// signature handler with the app's signing secret and a given tolerance for
+// stale timestamps.
+func NewSlackSignatureHandlerWithTolerance(signingSecret string, tolerance time.Duration) SlackSignatureHandler {
+	return SlackSignatureHandler{
+		signingSecret: signingSecret,
+		tolerance:     tolerance,
+		now:           time.Now,
+	}
+}
+
+// Before method that checks the request timestamp and the v0 signature
+// over the raw request body. It stops the request with a forbidden
+// response if either check fails.
+func (s *SlackSignatureHandler) Before(context *router.ContextMap, task *router.TaskMap) bool {
+	timestamp := task.Header(slackTimestampHeader)
+	signature := task.Header(slackSignatureHeader)
+
+	if !s.validTimestamp(timestamp) || !s.validSignature(timestamp, task.Body(), signature) {
+		setForbiddenErrCode(task)
+		return true
+	}
+
+	return false
+}
+
+// Execute method that does nothing.
+func (s *SlackSignatureHandler) Execute(context *router.ContextMap, task *router.TaskMap) {}
+
+// After method that does nothing.
+func (s *SlackSignatureHandler) After(context *router.ContextMap, task *router.TaskMap) {}
+
+func (s *SlackSignatureHandler) validTimestamp(timestamp string) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	age := s.now().Sub(time.Unix(seconds, 0))
+	if age < 0 {
+		age = -age
+	}
+
+	return age <= s.tolerance
+}
+
+func (s *SlackSignatureHandler) validSignature(timestamp string, body string, signature string) bool {
+	if s.signingSecret == "" || signature == "" {
+		return false
+	}
+
+	expected := signSlackRequest(s.signingSecret, timestamp, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func signSlackRequest(signingSecret string, timestamp string, body string) string {
+	mac := hmac.New(sha256.New, []byte(signingSecret))
+	mac.Write([]byte(slackSignatureVer + ":" + timestamp + ":" + body))
+	return slackSignatureVer + "=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+	testSlackBody     = "token=xyzz0WbapA4vBCDEFasx0q6G&team_id=T1DC2JH3J&command=%2Fweather&text=94070"
+)
+
+func newTestSlackTask(timestamp time.Time, secret string, body string) router.TaskMap {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	return router.TaskMap{
+		TaskBody: body,
+		TaskHeaders: map[string]string{
+			"x-slack-request-timestamp": ts,
+			"x-slack-signature":         signSlackRequest(secret, ts, body),
+		},
+	}
+}
+
+func TestSlackSignatureHandler(t *testing.T) {
+	now := time.Now()
+	testCtx := make(router.ContextMap)
+	testTask := newTestSlackTask(now, testSigningSecret, testSlackBody)
+	testHandler := NewSlackSignatureHandler(testSigningSecret)
+	testHandler.now = func() time.Time { return now }
+
+	assert.False(t, testHandler.Before(&testCtx, &testTask))
+	_, hasStatusCode := testTask.StatusCode()
+	assert.False(t, hasStatusCode)
+	assert.Equal(t, testTask[TaskBody], testSlackBody)
+}
+
+func TestSlackSignatureHandlerBadSignature(t *testing.T) {
+	now := time.Now()
+	testCtx := make(router.ContextMap)
+	testTask := newTestSlackTask(now, "wrong secret", testSlackBody)
+	testHandler := NewSlackSignatureHandler(testSigningSecret)
+	testHandler.now = func() time.Time { return now }
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	statusCode, _ := testTask.StatusCode()
+	assert.Equal(t, statusCode, http.StatusForbidden)
+}
+
+func TestSlackSignatureHandlerTamperedBody(t *testing.T) {
+	now := time.Now()
+	testCtx := make(router.ContextMap)
+	testTask := newTestSlackTask(now, testSigningSecret, testSlackBody)
+	testTask[TaskBody] = testSlackBody + "&user_id=U2147483697"
+	testHandler := NewSlackSignatureHandler(testSigningSecret)
+	testHandler.now = func() time.Time { return now }
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	statusCode, _ := testTask.StatusCode()
+	assert.Equal(t, statusCode, http.StatusForbidden)
+}
+
+func TestSlackSignatureHandlerStaleTimestamp(t *testing.T) {
+	now := time.Now()
+	testCtx := make(router.ContextMap)
+	testTask := newTestSlackTask(now.Add(-10*time.Minute), testSigningSecret, testSlackBody)
+	testHandler := NewSlackSignatureHandler(testSigningSecret)
+	testHandler.now = func() time.Time { return now }
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	statusCode, _ := testTask.StatusCode()
+	assert.Equal(t, statusCode, http.StatusForbidden)
+}
+
+func TestSlackSignatureHandlerMissingHeaders(t *testing.T) {
+	testCtx := make(router.ContextMap)
+	testTask := router.TaskMap{
+		TaskBody: testSlackBody,
+	}
+	testHandler := NewSlackSignatureHandler(testSigningSecret)
+
+	assert.True(t, testHandler.Before(&testCtx, &testTask))
+	statusCode, _ := testTask.StatusCode()
+	assert.Equal(t, statusCode, http.StatusForbidden)
+	assert.Contains(t, testTask.Body(), forbiddenErrRespMsg)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"net/http"
+)
+
+const (
+	errUpstreamStatusFmt = "Function responded with an unexpected status. Url: %s, Status: %d"
+)
+
+// StatusPolicy decides whether the status code of a function's response
+// makes it an error.
+type StatusPolicy func(statusCode int) bool
+
+// Non2xxIsError treats every response outside the 2xx range as an error.
+// It is the policy used by NewProxyHandler.
+func Non2xxIsError(statusCode int) bool {
+	return statusCode < 200 || statusCode > 299
+}
+
+// ServerErrorIsError treats only 5xx responses as errors, so that
+// functions can answer bad requests with a status and message of their
+// own.
+func ServerErrorIsError(statusCode int) bool {
+	return statusCode >= 500
+}
+
+// NeverError passes every response through, whatever its status.
+func NeverError(statusCode int) bool {
+	return false
+}
+
+// UpstreamStatusError is recorded on the task when a function responds
+// with a status its StatusPolicy treats as an error.
+type UpstreamStatusError struct {
+	// Url is the url the function was called at.
+	Url string
+	// StatusCode is the status code of the function's response.
+	StatusCode int
+	// Body is the body of the function's response.
+	Body string
+}
+
+func (e UpstreamStatusError) Error() string {
+	return fmt.Sprintf(errUpstreamStatusFmt, e.Url, e.StatusCode)
+}
+
+// copyResponseHeaders copies the content type, and the headers the proxy
+// handler passes through, from the function's response into the task.
+func (p *ProxyHandler) copyResponseHeaders(resp *http.Response, task *router.TaskMap) {
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		task.SetResponseHeader(contentTypeHeader, contentType)
+	}
+	for _, name := range p.responseHeaders {
+		if val := resp.Header.Get(name); val != "" {
+			task.SetResponseHeader(name, val)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package router
+
+import (
+	"context"
+	"github.com/phoenixcoder/slack-golang-sdk/slashcmd"
+	"strings"
+)
+
+// Caller identifies who invoked a slash command, and from where.
+type Caller struct {
+	TeamID    string
+	ChannelID string
+	UserID    string
+}
+
+// UserGroupResolver is the interface that wraps the IsMember method, which
+// reports whether the caller belongs to a Slack user group.
+type UserGroupResolver interface {
+	IsMember(ctx context.Context, caller Caller, group string) (bool, error)
+}
+
+// UserGroupMap resolves user groups from a map of group ids to the ids of
+// their members.
+type UserGroupMap map[string][]string
+
+// IsMember reports whether the caller's user id is listed under the group.
+func (m UserGroupMap) IsMember(ctx context.Context, caller Caller, group string) (bool, error) {
+	return containsID(m[group], caller.UserID), nil
+}
+
+// AccessList names the Slack teams, channels, users and user groups
+// allowed to use a command or function. A caller must match every kind
+// of id that is listed, with users and user groups counting as one kind:
+// a caller in any of the listed user groups is allowed even if their user
+// id is not listed. An empty list allows everyone.
+type AccessList struct {
+	Teams      []string `json:"teams"`
+	Channels   []string `json:"channels"`
+	Users      []string `json:"users"`
+	UserGroups []string `json:"userGroups"`
+}
+
+// Allows reports whether the list allows the caller. User groups are
+// checked with the resolver, and the caller is refused if it is nil.
+func (a *AccessList) Allows(ctx context.Context, caller Caller, resolver UserGroupResolver) (bool, error) {
+	if a == nil {
+		return true, nil
+	}
+	if len(a.Teams) > 0 && !containsID(a.Teams, caller.TeamID) {
+		return false, nil
+	}
+	if len(a.Channels) > 0 && !containsID(a.Channels, caller.ChannelID) {
+		return false, nil
+	}
+	if len(a.Users) == 0 && len(a.UserGroups) == 0 {
+		return true, nil
+	}
+	if containsID(a.Users, caller.UserID) {
+		return true, nil
+	}
+	if resolver == nil {
+		return false, nil
+	}
+
+	for _, group := range a.UserGroups {
+		isMember, err := resolver.IsMember(ctx, caller, group)
+		if err != nil {
+			return false, err
+		}
+		if isMember {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// AccessLists returns the access lists that apply to the command: the
+// command's own, followed by those of the groups and function its
+// arguments name, as far as they can be resolved. Built-in keywords are
+// passed over, so that '/cmd help group' is held to the group's access
+// list like '/cmd group help'. The error is a CommandNotFoundError if the
+// command is not registered.
+func (cr *commandRegistry) AccessLists(cmd *slashcmd.Info) ([]*AccessList, error) {
+	cmdRec, cmdRecOk := (*cr)[strings.ToLower(cmd.Command)]
+	if !cmdRecOk {
+		return nil, CommandNotFoundError{Command: cmd.Command}
+	}
+
+	lists := []*AccessList{cmdRec.Access}
+	functions := cmdRec.Functions
+	for _, arg := range cmd.Arguments {
+		if cmdRec.isReserved(arg, functions) {
+			if isBuiltinKeyword(arg) {
+				continue
+			}
+			break
+		}
+		funcRec, ok := functions.function(arg)
+		if !ok {
+			break
+		}
+		lists = append(lists, funcRec.Access)
+		if len(funcRec.Functions) == 0 {
+			break
+		}
+		functions = funcRec.Functions
+	}
+
+	return lists, nil
+}
+
+// AccessLists returns the access lists that apply to the command in the
+// current command registry.
+func (m *RegistryManager) AccessLists(cmd *slashcmd.Info) ([]*AccessList, error) {
+	return m.Registry().AccessLists(cmd)
+}
+
+func containsID(ids []string, id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, listedID := range ids {
+		if listedID == id {
+			return true
+		}
+	}
+
+	return false
+}
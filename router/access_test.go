@@ -0,0 +1,122 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"github.com/phoenixcoder/slack-golang-sdk/slashcmd"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type failingResolver struct{}
+
+func (r failingResolver) IsMember(ctx context.Context, caller Caller, group string) (bool, error) {
+	return false, errors.New("resolver unavailable")
+}
+
+func TestAccessListAllows(t *testing.T) {
+	groups := UserGroupMap{"S-ops": {"U-oncall"}}
+	list := &AccessList{
+		Teams:      []string{"T1"},
+		Channels:   []string{"C-ops", "C-deploy"},
+		Users:      []string{"U-admin"},
+		UserGroups: []string{"S-ops"},
+	}
+
+	testCases := []struct {
+		caller  Caller
+		allowed bool
+	}{
+		{Caller{TeamID: "T1", ChannelID: "C-ops", UserID: "U-admin"}, true},
+		{Caller{TeamID: "T1", ChannelID: "C-deploy", UserID: "U-oncall"}, true},
+		{Caller{TeamID: "T2", ChannelID: "C-ops", UserID: "U-admin"}, false},
+		{Caller{TeamID: "T1", ChannelID: "C-random", UserID: "U-admin"}, false},
+		{Caller{TeamID: "T1", ChannelID: "C-ops", UserID: "U-intern"}, false},
+		{Caller{}, false},
+	}
+	for _, testCase := range testCases {
+		allowed, err := list.Allows(context.Background(), testCase.caller, groups)
+		assert.Nil(t, err)
+		assert.Equal(t, allowed, testCase.allowed, testCase.caller)
+	}
+
+	allowed, err := list.Allows(context.Background(), Caller{TeamID: "T1", ChannelID: "C-ops", UserID: "U-oncall"}, nil)
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+
+	_, err = list.Allows(context.Background(), Caller{TeamID: "T1", ChannelID: "C-ops", UserID: "U-oncall"}, failingResolver{})
+	assert.NotNil(t, err)
+}
+
+func TestEmptyAccessListAllowsEveryone(t *testing.T) {
+	for _, list := range []*AccessList{nil, {}, {Teams: []string{"T1"}}} {
+		allowed, err := list.Allows(context.Background(), Caller{TeamID: "T1", UserID: "U1"}, nil)
+		assert.Nil(t, err)
+		assert.True(t, allowed)
+	}
+}
+
+func TestCommandRegistryAccessLists(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromContents([]byte(`{
+           "/ops": {
+               "access" : {"teams" : ["T1"]},
+               "functions" : {
+                   "db" : {
+                       "access" : {"channels" : ["C-db"]},
+                       "functions" : {
+                           "backup" : {
+                               "usage" : "/ops db backup",
+                               "access" : {"users" : ["U-dba"]}
+                           }
+                       }
+                   },
+                   "status" : {
+                       "usage" : "/ops status"
+                   }
+               }
+           }
+        }`))
+	assert.Nil(t, err)
+
+	lists, err := cmdReg.AccessLists(&slashcmd.Info{Command: "/ops", Arguments: []string{"db", "backup", "orders"}})
+	assert.Nil(t, err)
+	assert.Equal(t, lists, []*AccessList{{Teams: []string{"T1"}}, {Channels: []string{"C-db"}}, {Users: []string{"U-dba"}}})
+
+	lists, err = cmdReg.AccessLists(&slashcmd.Info{Command: "/ops", Arguments: []string{"status"}})
+	assert.Nil(t, err)
+	assert.Equal(t, lists, []*AccessList{{Teams: []string{"T1"}}, nil})
+
+	lists, err = cmdReg.AccessLists(&slashcmd.Info{Command: "/ops", Arguments: []string{"help", "db"}})
+	assert.Nil(t, err)
+	assert.Equal(t, lists, []*AccessList{{Teams: []string{"T1"}}, {Channels: []string{"C-db"}}})
+
+	lists, err = cmdReg.AccessLists(&slashcmd.Info{Command: "/ops", Arguments: []string{"db", "help", "backup"}})
+	assert.Nil(t, err)
+	assert.Equal(t, lists, []*AccessList{{Teams: []string{"T1"}}, {Channels: []string{"C-db"}}, {Users: []string{"U-dba"}}})
+
+	lists, err = cmdReg.AccessLists(&slashcmd.Info{Command: "/ops", Arguments: []string{"list"}})
+	assert.Nil(t, err)
+	assert.Equal(t, lists, []*AccessList{{Teams: []string{"T1"}}})
+
+	_, err = cmdReg.AccessLists(&slashcmd.Info{Command: "/build"})
+	assert.True(t, errors.As(err, &CommandNotFoundError{}))
+}
+
+func TestValidateRegistryAccess(t *testing.T) {
+	err := ValidateRegistry([]byte(`{
+           "/ops": {
+               "access" : {"teams" : "T1"},
+               "functions" : {
+                   "status" : {
+                       "usage" : "/ops status",
+                       "access" : ["U1"]
+                   }
+               }
+           }
+        }`))
+	var valErrs ValidationErrors
+	assert.True(t, errors.As(err, &valErrs))
+	assert.Len(t, valErrs, 2)
+	assert.Equal(t, valErrs[0].Path, "$['/ops'].access")
+	assert.Equal(t, valErrs[1].Path, "$['/ops'].functions['status'].access")
+}
@@ -0,0 +1,143 @@
+package router
+
+import (
+	"encoding/base64"
+	"github.com/aws/aws-lambda-go/events"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const (
+	cookieHeader       = "cookie"
+	errDecodeBodyFmt   = "Could not decode base64 request body. Error: %v\n"
+	errUnknownEventFmt = "Unsupported request type for adapter. Type: %T\n"
+)
+
+// APIGatewayProxyRequestAdapter is a RequestAdapter that converts an API
+// Gateway REST API (v1) proxy event into a task map. It accepts the event
+// as a value or a pointer.
+func APIGatewayProxyRequestAdapter(req interface{}) TaskMap {
+	var event events.APIGatewayProxyRequest
+	switch r := req.(type) {
+	case events.APIGatewayProxyRequest:
+		event = r
+	case *events.APIGatewayProxyRequest:
+		event = *r
+	default:
+		log.Printf(errUnknownEventFmt, req)
+		return TaskMap{}
+	}
+
+	headers := copyStringMap(event.Headers)
+	for key, vals := range event.MultiValueHeaders {
+		if _, ok := headers[key]; !ok && len(vals) > 0 {
+			headers[key] = strings.Join(vals, ",")
+		}
+	}
+
+	query := copyStringMap(event.QueryStringParameters)
+	for key, vals := range event.MultiValueQueryStringParameters {
+		if _, ok := query[key]; !ok && len(vals) > 0 {
+			query[key] = vals[0]
+		}
+	}
+
+	return TaskMap{
+		TaskHeadersKey:    headers,
+		TaskQueryKey:      query,
+		TaskPathParamsKey: copyStringMap(event.PathParameters),
+		TaskBodyKey:       decodeBody(event.Body, event.IsBase64Encoded),
+	}
+}
+
+// APIGatewayProxyResponseAdapter is a ResponseAdapter that converts a task
+// map into an API Gateway REST API (v1) proxy response.
+func APIGatewayProxyResponseAdapter(task *TaskMap) interface{} {
+	return events.APIGatewayProxyResponse{
+		StatusCode: responseStatusCode(task),
+		Headers:    responseHeaders(task),
+		Body:       responseBody(task),
+	}
+}
+
+// APIGatewayV2HTTPRequestAdapter is a RequestAdapter that converts an API
+// Gateway HTTP API (v2) event into a task map. It accepts the event as a
+// value or a pointer.
+func APIGatewayV2HTTPRequestAdapter(req interface{}) TaskMap {
+	var event events.APIGatewayV2HTTPRequest
+	switch r := req.(type) {
+	case events.APIGatewayV2HTTPRequest:
+		event = r
+	case *events.APIGatewayV2HTTPRequest:
+		event = *r
+	default:
+		log.Printf(errUnknownEventFmt, req)
+		return TaskMap{}
+	}
+
+	// HTTP APIs move cookies out of the headers, so they are put back for
+	// handlers that expect to find them there.
+	headers := copyStringMap(event.Headers)
+	if len(event.Cookies) > 0 {
+		headers[cookieHeader] = strings.Join(event.Cookies, "; ")
+	}
+
+	return TaskMap{
+		TaskHeadersKey:    headers,
+		TaskQueryKey:      copyStringMap(event.QueryStringParameters),
+		TaskPathParamsKey: copyStringMap(event.PathParameters),
+		TaskBodyKey:       decodeBody(event.Body, event.IsBase64Encoded),
+	}
+}
+
+// APIGatewayV2HTTPResponseAdapter is a ResponseAdapter that converts a task
+// map into an API Gateway HTTP API (v2) response.
+func APIGatewayV2HTTPResponseAdapter(task *TaskMap) interface{} {
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode: responseStatusCode(task),
+		Headers:    responseHeaders(task),
+		Body:       responseBody(task),
+	}
+}
+
+func decodeBody(body string, isBase64Encoded bool) string {
+	if !isBase64Encoded {
+		return body
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(body)
+	if err != nil {
+		log.Printf(errDecodeBodyFmt, err)
+		return body
+	}
+
+	return string(decoded)
+}
+
+func copyStringMap(src map[string]string) map[string]string {
+	dst := make(map[string]string, len(src))
+	for key, val := range src {
+		dst[key] = val
+	}
+
+	return dst
+}
+
+// responseStatusCode reads the status code from the task, defaulting to
+// 200 when no handler has set one.
+func responseStatusCode(task *TaskMap) int {
+	if statusCode, ok := task.StatusCode(); ok && statusCode > 0 {
+		return statusCode
+	}
+
+	return http.StatusOK
+}
+
+func responseHeaders(task *TaskMap) map[string]string {
+	return copyStringMap(task.ResponseHeaders())
+}
+
+func responseBody(task *TaskMap) string {
+	return task.Body()
+}
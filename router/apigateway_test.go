@@ -0,0 +1,100 @@
+package router
+
+import (
+	"encoding/base64"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+const (
+	testApiGwBody = "command=%2Fdeploy&text=ship"
+)
+
+func TestAPIGatewayProxyRequestAdapter(t *testing.T) {
+	testReq := events.APIGatewayProxyRequest{
+		Headers:                         map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+		MultiValueHeaders:               map[string][]string{"Accept": {"text/plain", "application/json"}},
+		QueryStringParameters:           map[string]string{"q": "1"},
+		MultiValueQueryStringParameters: map[string][]string{"r": {"2", "3"}},
+		PathParameters:                  map[string]string{"proxy": "slack"},
+		Body:                            base64.StdEncoding.EncodeToString([]byte(testApiGwBody)),
+		IsBase64Encoded:                 true,
+	}
+
+	for _, req := range []interface{}{testReq, &testReq} {
+		testTask := APIGatewayProxyRequestAdapter(req)
+
+		assert.Equal(t, testTask[TaskHeadersKey], map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+			"Accept":       "text/plain,application/json",
+		})
+		assert.Equal(t, testTask[TaskQueryKey], map[string]string{"q": "1", "r": "2"})
+		assert.Equal(t, testTask[TaskPathParamsKey], map[string]string{"proxy": "slack"})
+		assert.Equal(t, testTask[TaskBodyKey], testApiGwBody)
+	}
+}
+
+func TestAPIGatewayProxyRequestAdapterUnknownType(t *testing.T) {
+	testTask := APIGatewayProxyRequestAdapter(testApiGwBody)
+	assert.Empty(t, testTask)
+}
+
+func TestAPIGatewayProxyResponseAdapter(t *testing.T) {
+	testTask := TaskMap{
+		TaskStatusCodeKey:  http.StatusAccepted,
+		TaskRespHeadersKey: map[string]string{"Content-Type": "application/json"},
+		TaskBodyKey:        testBody,
+	}
+
+	testResp := APIGatewayProxyResponseAdapter(&testTask).(events.APIGatewayProxyResponse)
+
+	assert.Equal(t, testResp.StatusCode, http.StatusAccepted)
+	assert.Equal(t, testResp.Headers["Content-Type"], "application/json")
+	assert.Equal(t, testResp.Body, testBody)
+}
+
+func TestAPIGatewayProxyResponseAdapterDefaults(t *testing.T) {
+	testTask := TaskMap{}
+
+	testResp := APIGatewayProxyResponseAdapter(&testTask).(events.APIGatewayProxyResponse)
+
+	assert.Equal(t, testResp.StatusCode, http.StatusOK)
+	assert.Empty(t, testResp.Headers)
+	assert.Empty(t, testResp.Body)
+}
+
+func TestAPIGatewayV2HTTPRequestAdapter(t *testing.T) {
+	testReq := &events.APIGatewayV2HTTPRequest{
+		Headers:               map[string]string{"content-type": "application/x-www-form-urlencoded"},
+		Cookies:               []string{"a=1", "b=2"},
+		QueryStringParameters: map[string]string{"q": "1"},
+		PathParameters:        map[string]string{"proxy": "slack"},
+		Body:                  testApiGwBody,
+	}
+
+	testTask := APIGatewayV2HTTPRequestAdapter(testReq)
+
+	assert.Equal(t, testTask[TaskHeadersKey], map[string]string{
+		"content-type": "application/x-www-form-urlencoded",
+		"cookie":       "a=1; b=2",
+	})
+	assert.Equal(t, testTask[TaskQueryKey], map[string]string{"q": "1"})
+	assert.Equal(t, testTask[TaskPathParamsKey], map[string]string{"proxy": "slack"})
+	assert.Equal(t, testTask[TaskBodyKey], testApiGwBody)
+}
+
+func TestAPIGatewayV2HTTPResponseAdapter(t *testing.T) {
+	testTask := TaskMap{
+		TaskStatusCodeKey:  http.StatusNotFound,
+		TaskRespHeadersKey: map[string]string{"Content-Type": "text/plain"},
+		TaskBodyKey:        testBody,
+	}
+
+	testResp := APIGatewayV2HTTPResponseAdapter(&testTask).(events.APIGatewayV2HTTPResponse)
+
+	assert.Equal(t, testResp.StatusCode, http.StatusNotFound)
+	assert.Equal(t, testResp.Headers["Content-Type"], "text/plain")
+	assert.Equal(t, testResp.Body, testBody)
+}
@@ -0,0 +1,312 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	errArgUnexpectedFmt = "Unexpected argument '%s'."
+	errArgMissingFmt    = "Missing argument '%s'."
+	errFlagUnknownFmt   = "Unknown flag '--%s'."
+	errFlagMissingFmt   = "Missing flag '--%s'."
+	errFlagNoValueFmt   = "Flag '--%s' needs a value."
+	errFlagRepeatedFmt  = "Flag '--%s' was given more than once."
+	errArgIntFmt        = "'%s' must be a whole number, not '%s'."
+	errArgEnumFmt       = "'%s' must be one of '%s', not '%s'."
+	errArgDurationFmt   = "'%s' must be a duration such as 90s or 5m, not '%s'."
+	errArgUserFmt       = "'%s' must mention a user, not '%s'."
+	errArgChannelFmt    = "'%s' must mention a channel, not '%s'."
+	errArgTypeFmt       = "'%s' has an unknown type '%s'."
+
+	flagPrefix     = "--"
+	userMention    = "<@"
+	channelMention = "<#"
+)
+
+// ArgumentType is the type of value an argument accepts.
+type ArgumentType string
+
+const (
+	ArgString   ArgumentType = "string"
+	ArgInt      ArgumentType = "int"
+	ArgEnum     ArgumentType = "enum"
+	ArgDuration ArgumentType = "duration"
+	ArgUser     ArgumentType = "user"
+	ArgChannel  ArgumentType = "channel"
+)
+
+var argumentTypes = map[ArgumentType]bool{
+	ArgString:   true,
+	ArgInt:      true,
+	ArgEnum:     true,
+	ArgDuration: true,
+	ArgUser:     true,
+	ArgChannel:  true,
+}
+
+// ArgumentSpec declares a positional argument or --flag of a function.
+type ArgumentSpec struct {
+	// Name is the name the value is passed downstream under, and the name
+	// of a flag without its leading dashes.
+	Name string `json:"name"`
+	// Type is the type of value accepted. Defaults to string.
+	Type ArgumentType `json:"type"`
+	// Required arguments must be given, unless they have a default.
+	Required bool `json:"required"`
+	// Values are the values an enum accepts, matched case insensitively.
+	Values []string `json:"values"`
+	// Default is used in place of an argument that was not given.
+	Default string `json:"default"`
+	// Description is a description of what the argument is for.
+	Description string `json:"description"`
+}
+
+// Mention is a Slack user or channel mention, such as <@U024BE7LH|bob>.
+// Name is empty if Slack did not include one.
+type Mention struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// Arguments are the values of a function's declared arguments, by name.
+// Values are a string, an int, a time.Duration or a Mention, according to
+// the type of the argument. Arguments that were not given and have no
+// default are left out.
+type Arguments map[string]interface{}
+
+// String returns the value of a string or enum argument.
+func (a Arguments) String(name string) (string, bool) {
+	val, ok := a[name].(string)
+	return val, ok
+}
+
+// Int returns the value of an int argument.
+func (a Arguments) Int(name string) (int, bool) {
+	val, ok := a[name].(int)
+	return val, ok
+}
+
+// Duration returns the value of a duration argument.
+func (a Arguments) Duration(name string) (time.Duration, bool) {
+	val, ok := a[name].(time.Duration)
+	return val, ok
+}
+
+// Mention returns the value of a user or channel argument.
+func (a Arguments) Mention(name string) (Mention, bool) {
+	val, ok := a[name].(Mention)
+	return val, ok
+}
+
+// MarshalJSON writes durations as strings, such as "1m30s", rather than
+// as nanoseconds.
+func (a Arguments) MarshalJSON() ([]byte, error) {
+	vals := make(map[string]interface{}, len(a))
+	for name, val := range a {
+		if dur, ok := val.(time.Duration); ok {
+			val = dur.String()
+		}
+		vals[name] = val
+	}
+
+	return json.Marshal(vals)
+}
+
+// ArgumentError is returned when the arguments given to a function do not
+// match the arguments it declares.
+type ArgumentError struct {
+	// Function is the function's path, such as 'db backup'.
+	Function string
+	// Usage is the function's usage.
+	Usage string
+	// Msg says what was wrong with the arguments.
+	Msg string
+}
+
+func (e ArgumentError) Error() string {
+	return e.Msg
+}
+
+// HasArguments reports whether the function declares any arguments or
+// flags. Functions that do not are passed their arguments unparsed.
+func (f *FunctionRecord) HasArguments() bool {
+	return len(f.Arguments) > 0 || len(f.Flags) > 0
+}
+
+// ParseArguments parses the arguments that followed the function's name
+// against the positional arguments and flags it declares. Flags are given
+// as '--name value' or '--name=value', anywhere among the positional
+// arguments; everything after a bare '--' is positional. The error is an
+// ArgumentError if the arguments do not match.
+func (f *FunctionRecord) ParseArguments() (Arguments, error) {
+	args := make(Arguments)
+	position := 0
+	flagsDone := false
+	for i := 0; i < len(f.Args); i++ {
+		arg := f.Args[i]
+		if !flagsDone && arg == flagPrefix {
+			flagsDone = true
+			continue
+		}
+
+		if flagsDone || !strings.HasPrefix(arg, flagPrefix) {
+			if position >= len(f.Arguments) {
+				return nil, f.argumentError(fmt.Sprintf(errArgUnexpectedFmt, arg))
+			}
+			spec := f.Arguments[position]
+			position++
+			if err := f.setArgument(args, spec, arg); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		nameAndValue := strings.SplitN(strings.TrimPrefix(arg, flagPrefix), "=", 2)
+		name := nameAndValue[0]
+		spec, ok := f.flag(name)
+		if !ok {
+			return nil, f.argumentError(fmt.Sprintf(errFlagUnknownFmt, name))
+		}
+		if _, ok := args[spec.Name]; ok {
+			return nil, f.argumentError(fmt.Sprintf(errFlagRepeatedFmt, spec.Name))
+		}
+
+		var value string
+		if len(nameAndValue) == 2 {
+			value = nameAndValue[1]
+		} else if i+1 < len(f.Args) {
+			i++
+			value = f.Args[i]
+		} else {
+			return nil, f.argumentError(fmt.Sprintf(errFlagNoValueFmt, spec.Name))
+		}
+		if err := f.setArgument(args, spec, value); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, spec := range f.Arguments[position:] {
+		if err := f.setDefault(args, spec, errArgMissingFmt); err != nil {
+			return nil, err
+		}
+	}
+	for _, spec := range f.Flags {
+		if _, ok := args[spec.Name]; !ok {
+			if err := f.setDefault(args, spec, errFlagMissingFmt); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return args, nil
+}
+
+func (f *FunctionRecord) flag(name string) (ArgumentSpec, bool) {
+	for _, spec := range f.Flags {
+		if strings.EqualFold(spec.Name, name) {
+			return spec, true
+		}
+	}
+
+	return ArgumentSpec{}, false
+}
+
+// setDefault sets an argument that was not given to its default, or
+// returns an error if it is required.
+func (f *FunctionRecord) setDefault(args Arguments, spec ArgumentSpec, missingFmt string) error {
+	if spec.Default != "" {
+		return f.setArgument(args, spec, spec.Default)
+	}
+	if spec.Required {
+		return f.argumentError(fmt.Sprintf(missingFmt, spec.Name))
+	}
+
+	return nil
+}
+
+func (f *FunctionRecord) setArgument(args Arguments, spec ArgumentSpec, value string) error {
+	val, err := spec.Parse(value)
+	if err != nil {
+		return f.argumentError(err.Error())
+	}
+	args[spec.Name] = val
+
+	return nil
+}
+
+func (f *FunctionRecord) argumentError(msg string) ArgumentError {
+	function := strings.Join(f.Path, " ")
+	if function == "" {
+		function = f.Name
+	}
+
+	return ArgumentError{
+		Function: function,
+		Usage:    f.Usage,
+		Msg:      msg,
+	}
+}
+
+// Parse converts a value given for the argument to its type.
+func (s ArgumentSpec) Parse(value string) (interface{}, error) {
+	switch s.Type {
+	case "", ArgString:
+		return value, nil
+	case ArgInt:
+		val, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf(errArgIntFmt, s.Name, value)
+		}
+		return val, nil
+	case ArgEnum:
+		for _, allowed := range s.Values {
+			if strings.EqualFold(allowed, value) {
+				return allowed, nil
+			}
+		}
+		return nil, fmt.Errorf(errArgEnumFmt, s.Name, strings.Join(s.Values, "', '"), value)
+	case ArgDuration:
+		val, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf(errArgDurationFmt, s.Name, value)
+		}
+		return val, nil
+	case ArgUser:
+		val, ok := parseMention(value, userMention)
+		if !ok {
+			return nil, fmt.Errorf(errArgUserFmt, s.Name, value)
+		}
+		return val, nil
+	case ArgChannel:
+		val, ok := parseMention(value, channelMention)
+		if !ok {
+			return nil, fmt.Errorf(errArgChannelFmt, s.Name, value)
+		}
+		return val, nil
+	}
+
+	return nil, fmt.Errorf(errArgTypeFmt, s.Name, s.Type)
+}
+
+// parseMention parses an escaped Slack mention, such as <@U024BE7LH> or
+// <#C024BE7LR|general>.
+func parseMention(value string, prefix string) (Mention, bool) {
+	if !strings.HasPrefix(value, prefix) || !strings.HasSuffix(value, ">") {
+		return Mention{}, false
+	}
+
+	idAndName := strings.SplitN(value[len(prefix):len(value)-1], "|", 2)
+	if idAndName[0] == "" {
+		return Mention{}, false
+	}
+	mention := Mention{ID: idAndName[0]}
+	if len(idAndName) == 2 {
+		mention.Name = idAndName[1]
+	}
+
+	return mention, true
+}
@@ -0,0 +1,134 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/phoenixcoder/slack-golang-sdk/slashcmd"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func newTestArgsFunctionRecord(args ...string) *FunctionRecord {
+	return &FunctionRecord{
+		Name:  "ship",
+		Path:  []string{"deploy", "ship"},
+		Args:  args,
+		Usage: "/ops deploy ship <service> [env] [--wait <duration>] [--notify <user>]",
+		Arguments: []ArgumentSpec{
+			{Name: "service", Required: true},
+			{Name: "env", Type: ArgEnum, Values: []string{"staging", "prod"}, Default: "staging"},
+		},
+		Flags: []ArgumentSpec{
+			{Name: "wait", Type: ArgDuration},
+			{Name: "replicas", Type: ArgInt},
+			{Name: "notify", Type: ArgUser},
+			{Name: "channel", Type: ArgChannel},
+		},
+	}
+}
+
+func TestParseArguments(t *testing.T) {
+	funcRec := newTestArgsFunctionRecord("api", "--wait", "90s", "PROD", "--replicas=3", "--notify", "<@U024BE7LH|bob>", "--channel=<#C024BE7LR>")
+
+	args, err := funcRec.ParseArguments()
+	assert.Nil(t, err)
+	assert.Equal(t, args, Arguments{
+		"service":  "api",
+		"env":      "prod",
+		"wait":     90 * time.Second,
+		"replicas": 3,
+		"notify":   Mention{ID: "U024BE7LH", Name: "bob"},
+		"channel":  Mention{ID: "C024BE7LR"},
+	})
+
+	service, ok := args.String("service")
+	assert.True(t, ok)
+	assert.Equal(t, service, "api")
+	replicas, ok := args.Int("replicas")
+	assert.True(t, ok)
+	assert.Equal(t, replicas, 3)
+	wait, ok := args.Duration("wait")
+	assert.True(t, ok)
+	assert.Equal(t, wait, 90*time.Second)
+	notify, ok := args.Mention("notify")
+	assert.True(t, ok)
+	assert.Equal(t, notify.Name, "bob")
+	_, ok = args.Int("service")
+	assert.False(t, ok)
+}
+
+func TestParseArgumentsDefaults(t *testing.T) {
+	args, err := newTestArgsFunctionRecord("--", "--api").ParseArguments()
+	assert.Nil(t, err)
+	assert.Equal(t, args, Arguments{"service": "--api", "env": "staging"})
+}
+
+func TestParseArgumentsErrors(t *testing.T) {
+	testCases := []struct {
+		args []string
+		msg  string
+	}{
+		{[]string{}, "Missing argument 'service'."},
+		{[]string{"api", "prod", "extra"}, "Unexpected argument 'extra'."},
+		{[]string{"api", "dev"}, "'env' must be one of 'staging', 'prod', not 'dev'."},
+		{[]string{"api", "--force"}, "Unknown flag '--force'."},
+		{[]string{"api", "--wait"}, "Flag '--wait' needs a value."},
+		{[]string{"api", "--wait", "soon"}, "'wait' must be a duration such as 90s or 5m, not 'soon'."},
+		{[]string{"api", "--replicas=3", "--replicas=4"}, "Flag '--replicas' was given more than once."},
+		{[]string{"api", "--replicas", "three"}, "'replicas' must be a whole number, not 'three'."},
+		{[]string{"api", "--notify", "@bob"}, "'notify' must mention a user, not '@bob'."},
+		{[]string{"api", "--notify", "<#C024BE7LR>"}, "'notify' must mention a user, not '<#C024BE7LR>'."},
+		{[]string{"api", "--channel", "<#>"}, "'channel' must mention a channel, not '<#>'."},
+	}
+
+	for _, testCase := range testCases {
+		funcRec := newTestArgsFunctionRecord(testCase.args...)
+		_, err := funcRec.ParseArguments()
+		var argErr ArgumentError
+		assert.True(t, errors.As(err, &argErr), testCase.args)
+		assert.Equal(t, argErr.Msg, testCase.msg)
+		assert.Equal(t, argErr.Function, "deploy ship")
+		assert.Equal(t, argErr.Usage, funcRec.Usage)
+	}
+}
+
+func TestParseArgumentsRequiredFlag(t *testing.T) {
+	funcRec := &FunctionRecord{Name: "ship", Flags: []ArgumentSpec{{Name: "service", Required: true}}}
+
+	_, err := funcRec.ParseArguments()
+	assert.Equal(t, err, ArgumentError{Function: "ship", Msg: "Missing flag '--service'."})
+}
+
+func TestArgumentsMarshalJSON(t *testing.T) {
+	encoded, err := json.Marshal(Arguments{
+		"wait":   90 * time.Second,
+		"count":  2,
+		"notify": Mention{ID: "U024BE7LH"},
+	})
+	assert.Nil(t, err)
+	assert.JSONEq(t, string(encoded), `{"wait": "1m30s", "count": 2, "notify": {"id": "U024BE7LH"}}`)
+}
+
+func TestLoadRegistryArguments(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromContents([]byte(`{
+           "/deploy": {
+               "functions" : {
+                   "ship" : {
+                       "usage" : "/deploy ship <service>",
+                       "arguments" : [{"name" : "service", "required" : true}],
+                       "flags" : [{"name" : "wait", "type" : "duration", "default" : "1m"}]
+                   }
+               }
+           }
+        }`))
+	assert.Nil(t, err)
+
+	funcRec, err := cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "/deploy", Arguments: []string{"ship", "api"}})
+	assert.Nil(t, err)
+	assert.True(t, funcRec.HasArguments())
+
+	args, err := funcRec.ParseArguments()
+	assert.Nil(t, err)
+	assert.Equal(t, args, Arguments{"service": "api", "wait": time.Minute})
+}
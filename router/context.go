@@ -0,0 +1,82 @@
+package router
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// RequestContextKey is the context map key for the context.Context of
+	// the request being handled.
+	RequestContextKey = "request-context"
+
+	// DefaultDeadlineMargin is how long before the caller's deadline the
+	// context handed to the handlers is cancelled, leaving time to build
+	// a response.
+	DefaultDeadlineMargin = 250 * time.Millisecond
+)
+
+// Context returns the context.Context of the request being handled, or
+// context.Background if the router was not given one.
+func (c *ContextMap) Context() context.Context {
+	if c != nil {
+		if ctx, ok := (*c)[RequestContextKey].(context.Context); ok {
+			return ctx
+		}
+	}
+
+	return context.Background()
+}
+
+// ContextHandler is the context-aware counterpart of Handler. Each method
+// receives the context.Context of the request, which carries its deadline
+// and cancellation.
+type ContextHandler interface {
+	BeforeContext(ctx context.Context, context *ContextMap, task *TaskMap) bool
+	ExecuteContext(ctx context.Context, context *ContextMap, task *TaskMap)
+	AfterContext(ctx context.Context, context *ContextMap, task *TaskMap)
+}
+
+// AdaptContextHandler wraps a ContextHandler so it can be placed in a
+// chain of handlers. The context.Context is read from the context map.
+func AdaptContextHandler(h ContextHandler) Handler {
+	return &contextHandlerAdapter{handler: h}
+}
+
+// AdaptHandler wraps an existing Handler so it can be used where a
+// ContextHandler is expected. The context.Context is ignored.
+func AdaptHandler(h Handler) ContextHandler {
+	return &handlerAdapter{handler: h}
+}
+
+type contextHandlerAdapter struct {
+	handler ContextHandler
+}
+
+func (a *contextHandlerAdapter) Before(context *ContextMap, task *TaskMap) bool {
+	return a.handler.BeforeContext(context.Context(), context, task)
+}
+
+func (a *contextHandlerAdapter) Execute(context *ContextMap, task *TaskMap) {
+	a.handler.ExecuteContext(context.Context(), context, task)
+}
+
+func (a *contextHandlerAdapter) After(context *ContextMap, task *TaskMap) {
+	a.handler.AfterContext(context.Context(), context, task)
+}
+
+type handlerAdapter struct {
+	handler Handler
+}
+
+func (a *handlerAdapter) BeforeContext(ctx context.Context, context *ContextMap, task *TaskMap) bool {
+	return a.handler.Before(context, task)
+}
+
+func (a *handlerAdapter) ExecuteContext(ctx context.Context, context *ContextMap, task *TaskMap) {
+	a.handler.Execute(context, task)
+}
+
+func (a *handlerAdapter) AfterContext(ctx context.Context, context *ContextMap, task *TaskMap) {
+	a.handler.After(context, task)
+}
@@ -0,0 +1,110 @@
+package router
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+type mockContextHandler struct {
+	mock.Mock
+}
+
+func (m *mockContextHandler) BeforeContext(ctx context.Context, context *ContextMap, task *TaskMap) bool {
+	args := m.Called(ctx, context, task)
+
+	return args.Bool(0)
+}
+
+func (m *mockContextHandler) ExecuteContext(ctx context.Context, context *ContextMap, task *TaskMap) {
+	m.Called(ctx, context, task)
+}
+
+func (m *mockContextHandler) AfterContext(ctx context.Context, context *ContextMap, task *TaskMap) {
+	m.Called(ctx, context, task)
+}
+
+func TestContextMapDefaultContext(t *testing.T) {
+	var nilCtx *ContextMap
+	testCtx := make(ContextMap)
+
+	assert.Equal(t, nilCtx.Context(), context.Background())
+	assert.Equal(t, testCtx.Context(), context.Background())
+}
+
+func TestRouterHandleContext(t *testing.T) {
+	type ctxKey string
+	parentCtx := context.WithValue(context.Background(), ctxKey(testCtxKey), testCtxContent)
+	parentCtx, cancel := context.WithTimeout(parentCtx, time.Minute)
+	defer cancel()
+	parentDeadline, _ := parentCtx.Deadline()
+
+	isExpectedCtx := func(ctx context.Context) bool {
+		deadline, ok := ctx.Deadline()
+		return ok && ctx.Value(ctxKey(testCtxKey)) == testCtxContent &&
+			!deadline.After(parentDeadline.Add(-time.Second))
+	}
+	mockCtxHandler := new(mockContextHandler)
+	mockCtxHandler.On("BeforeContext", mock.MatchedBy(isExpectedCtx), mock.Anything, mock.Anything).Return(false)
+	mockCtxHandler.On("ExecuteContext", mock.MatchedBy(isExpectedCtx), mock.Anything, mock.Anything)
+	mockCtxHandler.On("AfterContext", mock.MatchedBy(isExpectedCtx), mock.Anything, mock.Anything)
+	mockReqHelper := &mockRequestHelper{
+		task: make(TaskMap),
+	}
+	mockRespHelper := &mockResponseHelper{
+		response: testBody,
+	}
+
+	testRouter := NewRouter(mockReqHelper.mockRequestAdapter, mockRespHelper.mockResponseAdapter, AdaptContextHandler(mockCtxHandler))
+	testRouter.SetDeadlineMargin(time.Second)
+	testRes := testRouter.HandleContext(parentCtx, new(mockRequest))
+
+	assert.Equal(t, testRes.(string), testBody)
+	mockCtxHandler.AssertNumberOfCalls(t, "BeforeContext", 1)
+	mockCtxHandler.AssertNumberOfCalls(t, "ExecuteContext", 1)
+	mockCtxHandler.AssertNumberOfCalls(t, "AfterContext", 1)
+}
+
+func TestAdaptHandler(t *testing.T) {
+	testCtx := make(ContextMap)
+	testTask := make(TaskMap)
+	mockHandler1 := new(mockHandler)
+	mockHandler1.On("Before", &testCtx, &testTask).Return(true)
+	mockHandler1.On("Execute", &testCtx, &testTask)
+	mockHandler1.On("After", &testCtx, &testTask)
+
+	testHandler := AdaptHandler(mockHandler1)
+
+	assert.True(t, testHandler.BeforeContext(context.Background(), &testCtx, &testTask))
+	testHandler.ExecuteContext(context.Background(), &testCtx, &testTask)
+	testHandler.AfterContext(context.Background(), &testCtx, &testTask)
+	mockHandler1.AssertNumberOfCalls(t, "Before", 1)
+	mockHandler1.AssertNumberOfCalls(t, "Execute", 1)
+	mockHandler1.AssertNumberOfCalls(t, "After", 1)
+}
+
+func TestRouterCreatesContextBeforeAdaptingRequest(t *testing.T) {
+	var calls []string
+	ctxCreator := func() ContextMap {
+		calls = append(calls, "createContext")
+		return make(ContextMap)
+	}
+	reqAdapter := func(req interface{}) TaskMap {
+		calls = append(calls, "adaptRequest")
+		return make(TaskMap)
+	}
+	mockRespHelper := &mockResponseHelper{
+		response: testBody,
+	}
+	mockHandler1 := new(mockHandler)
+	mockHandler1.On("Before", mock.Anything, mock.Anything).Return(false)
+	mockHandler1.On("Execute", mock.Anything, mock.Anything)
+	mockHandler1.On("After", mock.Anything, mock.Anything)
+
+	testRouter := NewRouterWithContextCreator(ctxCreator, reqAdapter, mockRespHelper.mockResponseAdapter, mockHandler1)
+	testRouter.Handle(new(mockRequest))
+
+	assert.Equal(t, calls, []string{"createContext", "adaptRequest"})
+}
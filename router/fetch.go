@@ -0,0 +1,175 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+)
+
+const (
+	errRegStatusFmt      = "Unexpected status downloading registry. Status: %s"
+	errRegTooLargeFmt    = "Registry is larger than the limit of %d bytes."
+	errRegUrlSchemeFmt   = "Registry url is not an http(s) url: '%s'"
+	logRegRetryFmt       = "Retrying registry download in %s. Attempt: %d, Error: %v\n"
+	etagHeader           = "ETag"
+	ifNoneMatchHeader    = "If-None-Match"
+	authorizationHeader  = "Authorization"
+	defaultRegUrlTimeout = 10 * time.Second
+	defaultRegRetries    = 2
+	defaultRegBackoff    = 500 * time.Millisecond
+	defaultRegMaxBody    = 4 << 20
+)
+
+// RegistryHTTPOptions configures how registries are downloaded from urls.
+type RegistryHTTPOptions struct {
+	// Timeout bounds each attempt, including reading the body.
+	Timeout time.Duration
+	// Retries is the number of attempts made after the first fails with a
+	// network error, a 5xx status or a 429 status.
+	Retries int
+	// Backoff is the wait before the first retry. It doubles on every
+	// retry after that.
+	Backoff time.Duration
+	// Authorization is sent as the Authorization header when it is set,
+	// e.g. "Bearer <token>".
+	Authorization string
+	// MaxBodySize is the largest registry, in bytes, that is accepted.
+	MaxBodySize int64
+}
+
+// DefaultRegistryHTTPOptions returns the options used when downloading a
+// registry without a client of its own.
+func DefaultRegistryHTTPOptions() RegistryHTTPOptions {
+	return RegistryHTTPOptions{
+		Timeout:     defaultRegUrlTimeout,
+		Retries:     defaultRegRetries,
+		Backoff:     defaultRegBackoff,
+		MaxBodySize: defaultRegMaxBody,
+	}
+}
+
+// RegistryHTTPClient downloads registries from urls. Only 2xx responses
+// are accepted as registries.
+type RegistryHTTPClient struct {
+	client        *http.Client
+	retries       int
+	backoff       time.Duration
+	authorization string
+	maxBodySize   int64
+	sleep         func(time.Duration)
+}
+
+// NewRegistryHTTPClient is a factory method for creating a client with the
+// given options. Timeout, Backoff and MaxBodySize take their defaults
+// when they are zero or less.
+func NewRegistryHTTPClient(opts RegistryHTTPOptions) *RegistryHTTPClient {
+	defaults := DefaultRegistryHTTPOptions()
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaults.Timeout
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = defaults.Backoff
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = defaults.MaxBodySize
+	}
+	if opts.Retries < 0 {
+		opts.Retries = 0
+	}
+
+	return &RegistryHTTPClient{
+		client:        &http.Client{Timeout: opts.Timeout},
+		retries:       opts.Retries,
+		backoff:       opts.Backoff,
+		authorization: opts.Authorization,
+		maxBodySize:   opts.MaxBodySize,
+		sleep:         time.Sleep,
+	}
+}
+
+// registryDownload is a downloaded registry. Only notModified is set when
+// the server reports the registry matches the ETag it was asked about.
+type registryDownload struct {
+	contents    []byte
+	contentType string
+	etag        string
+	notModified bool
+}
+
+// fetch downloads the registry at the url, retrying failures that may be
+// temporary. If etag is set, the server may answer that the registry is
+// not modified.
+func (c *RegistryHTTPClient) fetch(ctx context.Context, url string, etag string) (*registryDownload, error) {
+	log.Printf(logRegUrl, url)
+
+	var dl *registryDownload
+	var retry bool
+	var err error
+	for attempt := 0; ; attempt++ {
+		dl, retry, err = c.get(ctx, url, etag)
+		if err == nil {
+			return dl, nil
+		}
+		if !retry || attempt >= c.retries {
+			break
+		}
+
+		wait := c.backoff << uint(attempt)
+		log.Printf(logRegRetryFmt, wait, attempt+1, err)
+		c.sleep(wait)
+	}
+
+	log.Printf(errDlRegFmt, err)
+	return nil, err
+}
+
+// get makes a single attempt at downloading the registry, and reports
+// whether a failed attempt is worth retrying.
+func (c *RegistryHTTPClient) get(ctx context.Context, url string, etag string) (*registryDownload, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, false, fmt.Errorf(errRegUrlSchemeFmt, url)
+	}
+	if c.authorization != "" {
+		req.Header.Set(authorizationHeader, c.authorization)
+	}
+	if etag != "" {
+		req.Header.Set(ifNoneMatchHeader, etag)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && etag != "" {
+		return &registryDownload{notModified: true}, false, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return nil, retry, fmt.Errorf(errRegStatusFmt, resp.Status)
+	}
+
+	contents, err := ioutil.ReadAll(io.LimitReader(resp.Body, c.maxBodySize+1))
+	if err != nil {
+		log.Printf(errReadRegFmt, err)
+		return nil, true, err
+	}
+	if int64(len(contents)) > c.maxBodySize {
+		return nil, false, fmt.Errorf(errRegTooLargeFmt, c.maxBodySize)
+	}
+
+	return &registryDownload{
+		contents:    contents,
+		contentType: resp.Header.Get(contentTypeHeader),
+		etag:        resp.Header.Get(etagHeader),
+	}, false, nil
+}
@@ -0,0 +1,128 @@
+package router
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRegistryHTTPClient(opts RegistryHTTPOptions, waits *[]time.Duration) *RegistryHTTPClient {
+	client := NewRegistryHTTPClient(opts)
+	client.sleep = func(wait time.Duration) {
+		*waits = append(*waits, wait)
+	}
+
+	return client
+}
+
+func TestRegistryHTTPClientRetriesWithBackoff(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write([]byte(testRegYaml))
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	client := newTestRegistryHTTPClient(RegistryHTTPOptions{Retries: 2, Backoff: time.Second}, &waits)
+
+	cmdReg, err := NewCommandRegistryFromSource(NewURLSourceWithClient(server.URL, client))
+	assertTestShipRegistry(t, cmdReg, err)
+	assert.Equal(t, attempts, 3)
+	assert.Equal(t, waits, []time.Duration{time.Second, 2 * time.Second})
+}
+
+func TestRegistryHTTPClientGivesUpAfterRetries(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.Error(w, "<html>oops</html>", http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	client := newTestRegistryHTTPClient(RegistryHTTPOptions{Retries: 1}, &waits)
+
+	_, err := client.fetch(context.Background(), server.URL, "")
+	assert.Equal(t, err.Error(), "Unexpected status downloading registry. Status: 500 Internal Server Error")
+	assert.Equal(t, attempts, 2)
+	assert.Equal(t, waits, []time.Duration{defaultRegBackoff})
+}
+
+func TestRegistryHTTPClientDoesNotRetryClientErrors(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	client := newTestRegistryHTTPClient(RegistryHTTPOptions{Retries: 3}, &waits)
+
+	cmdReg, err := NewCommandRegistryFromSource(NewURLSourceWithClient(server.URL, client))
+	assert.Nil(t, cmdReg)
+	assert.Contains(t, err.Error(), "404")
+	assert.Equal(t, attempts, 1)
+	assert.Empty(t, waits)
+}
+
+func TestRegistryHTTPClientSendsAuthorization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(testRegJson))
+	}))
+	defer server.Close()
+
+	client := NewRegistryHTTPClient(RegistryHTTPOptions{Authorization: "Bearer token"})
+	cmdReg, err := NewCommandRegistryFromSource(NewURLSourceWithClient(server.URL, client))
+	assert.Nil(t, err)
+	assert.NotNil(t, cmdReg)
+
+	cmdReg, err = NewCommandRegistryFromSource(NewURLSourceWithClient(server.URL, NewRegistryHTTPClient(RegistryHTTPOptions{})))
+	assert.Nil(t, cmdReg)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestRegistryHTTPClientMaxBodySize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat(" ", 64) + testRegJson))
+	}))
+	defer server.Close()
+
+	client := NewRegistryHTTPClient(RegistryHTTPOptions{MaxBodySize: 64})
+	_, err := client.fetch(context.Background(), server.URL, "")
+	assert.Equal(t, err.Error(), "Registry is larger than the limit of 64 bytes.")
+}
+
+func TestRegistryHTTPClientTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	var waits []time.Duration
+	client := newTestRegistryHTTPClient(RegistryHTTPOptions{Timeout: 10 * time.Millisecond}, &waits)
+
+	_, err := client.fetch(context.Background(), server.URL, "")
+	assert.NotNil(t, err)
+}
+
+func TestRegistryHTTPClientRejectsNonHttpUrls(t *testing.T) {
+	_, err := NewRegistryHTTPClient(DefaultRegistryHTTPOptions()).fetch(context.Background(), "/etc/registry.json", "")
+	assert.Equal(t, err.Error(), "Registry url is not an http(s) url: '/etc/registry.json'")
+}
@@ -0,0 +1,123 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	errUnknownFormatFmt = "Unknown registry format: '%s'"
+	errConvertRegFmt    = "Could not convert %s registry to JSON. Error: %v"
+)
+
+// RegistryFormat is the document format a registry is written in.
+type RegistryFormat string
+
+const (
+	FormatJSON RegistryFormat = "json"
+	FormatYAML RegistryFormat = "yaml"
+	FormatTOML RegistryFormat = "toml"
+)
+
+var extFormats = map[string]RegistryFormat{
+	".json": FormatJSON,
+	".yaml": FormatYAML,
+	".yml":  FormatYAML,
+	".toml": FormatTOML,
+}
+
+var mediaTypeFormats = map[string]RegistryFormat{
+	"application/json":   FormatJSON,
+	"text/json":          FormatJSON,
+	"application/yaml":   FormatYAML,
+	"application/x-yaml": FormatYAML,
+	"text/yaml":          FormatYAML,
+	"text/x-yaml":        FormatYAML,
+	"application/toml":   FormatTOML,
+	"text/toml":          FormatTOML,
+	"text/x-toml":        FormatTOML,
+}
+
+// NewCommandRegistryFromFormat loads a registry from a document in the
+// given format. YAML and TOML documents are converted to JSON, and then
+// loaded like NewCommandRegistryFromContents.
+func NewCommandRegistryFromFormat(contents []byte, format RegistryFormat) (*commandRegistry, error) {
+	jsonContents, err := toJSON(contents, format)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCommandRegistryFromContents(jsonContents)
+}
+
+// ValidateRegistryFormat behaves like ValidateRegistry for a document in
+// the given format. Paths in the errors refer to the converted document,
+// which has the same structure as the original.
+func ValidateRegistryFormat(contents []byte, format RegistryFormat) error {
+	jsonContents, err := toJSON(contents, format)
+	if err != nil {
+		return ValidationErrors{{Path: rootPath, Msg: err.Error()}}
+	}
+
+	return ValidateRegistry(jsonContents)
+}
+
+// FormatFromPath detects the format of a registry from the extension of
+// a file path or url path, defaulting to JSON.
+func FormatFromPath(filePath string) RegistryFormat {
+	if format, ok := extFormats[strings.ToLower(path.Ext(filePath))]; ok {
+		return format
+	}
+
+	return FormatJSON
+}
+
+// formatFromResponse detects the format of a downloaded registry from its
+// Content-Type, falling back to the extension of the url path when the
+// content type does not name a format.
+func formatFromResponse(contentType string, rawUrl string) RegistryFormat {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		if format, ok := mediaTypeFormats[strings.ToLower(mediaType)]; ok {
+			return format
+		}
+	}
+
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return FormatJSON
+	}
+
+	return FormatFromPath(parsedUrl.Path)
+}
+
+func toJSON(contents []byte, format RegistryFormat) ([]byte, error) {
+	var doc interface{}
+	switch format {
+	case FormatJSON, "":
+		return contents, nil
+	case FormatYAML:
+		if err := yaml.Unmarshal(contents, &doc); err != nil {
+			return nil, fmt.Errorf(errConvertRegFmt, format, err)
+		}
+	case FormatTOML:
+		if err := toml.Unmarshal(contents, &doc); err != nil {
+			return nil, fmt.Errorf(errConvertRegFmt, format, err)
+		}
+	default:
+		return nil, fmt.Errorf(errUnknownFormatFmt, format)
+	}
+
+	jsonContents, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf(errConvertRegFmt, format, err)
+	}
+
+	return jsonContents, nil
+}
@@ -0,0 +1,119 @@
+package router
+
+import (
+	"github.com/phoenixcoder/slack-golang-sdk/slashcmd"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	testRegYaml = `
+/deploy:
+  reservedKeywords: [help]
+  functions:
+    Ship:
+      usage: /deploy ship <service>
+      description: Ships a service.
+      url: https://example.com/ship
+      timeout: 2s
+`
+	testRegToml = `
+["/deploy"]
+reservedKeywords = ["help"]
+
+["/deploy".functions.Ship]
+usage = "/deploy ship <service>"
+description = "Ships a service."
+url = "https://example.com/ship"
+timeout = "2s"
+`
+)
+
+func assertTestShipRegistry(t *testing.T, cmdReg *commandRegistry, err error) {
+	assert.Nil(t, err)
+	if cmdReg == nil {
+		return
+	}
+
+	funcRec, err := cmdReg.GetFunctionRecord(&slashcmd.Info{
+		Command:   "/deploy",
+		Arguments: []string{"ship"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, funcRec.Usage, "/deploy ship <service>")
+	assert.Equal(t, funcRec.Url, "https://example.com/ship")
+	assert.Equal(t, time.Duration(funcRec.Timeout), 2*time.Second)
+	assert.Equal(t, (*cmdReg)["/deploy"].ReservedKeywords, []string{"help"})
+}
+
+func TestNewCommandRegistryFromFormat(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromFormat([]byte(testRegYaml), FormatYAML)
+	assertTestShipRegistry(t, cmdReg, err)
+
+	cmdReg, err = NewCommandRegistryFromFormat([]byte(testRegToml), FormatTOML)
+	assertTestShipRegistry(t, cmdReg, err)
+
+	cmdReg, err = NewCommandRegistryFromFormat([]byte(testRegJson), FormatJSON)
+	assert.Nil(t, err)
+	assert.NotNil(t, cmdReg)
+}
+
+func TestNewCommandRegistryFromFormatErrors(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromFormat([]byte("key: [unclosed"), FormatYAML)
+	assert.Nil(t, cmdReg)
+	assert.NotNil(t, err)
+
+	cmdReg, err = NewCommandRegistryFromFormat([]byte(testRegYaml), RegistryFormat("xml"))
+	assert.Nil(t, cmdReg)
+	assert.NotNil(t, err)
+
+	err = ValidateRegistryFormat([]byte("/deploy:\n  functions: {}\n"), FormatYAML)
+	assert.Equal(t, err.Error(), "$['/deploy'].functions "+errEmptyFunctionsMsg)
+}
+
+func TestFormatFromPath(t *testing.T) {
+	assert.Equal(t, FormatFromPath("registry.yaml"), FormatYAML)
+	assert.Equal(t, FormatFromPath("/etc/registry.YML"), FormatYAML)
+	assert.Equal(t, FormatFromPath("registry.toml"), FormatTOML)
+	assert.Equal(t, FormatFromPath("registry.json"), FormatJSON)
+	assert.Equal(t, FormatFromPath("registry"), FormatJSON)
+}
+
+func TestNewCommandRegistryFromFileFormats(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "registry.yml")
+	tomlPath := filepath.Join(dir, "registry.toml")
+	assert.Nil(t, ioutil.WriteFile(yamlPath, []byte(testRegYaml), 0644))
+	assert.Nil(t, ioutil.WriteFile(tomlPath, []byte(testRegToml), 0644))
+
+	cmdReg, err := NewCommandRegistryFromFile(yamlPath)
+	assertTestShipRegistry(t, cmdReg, err)
+
+	cmdReg, err = NewCommandRegistryFromFile(tomlPath)
+	assertTestShipRegistry(t, cmdReg, err)
+}
+
+func TestNewCommandRegistryFromUrlFormats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/registry":
+			w.Header().Set("Content-Type", "application/x-yaml; charset=utf-8")
+			w.Write([]byte(testRegYaml))
+		case "/registry.toml":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte(testRegToml))
+		}
+	}))
+	defer server.Close()
+
+	cmdReg, err := NewCommandRegistryFromUrl(server.URL + "/registry")
+	assertTestShipRegistry(t, cmdReg, err)
+
+	cmdReg, err = NewCommandRegistryFromUrl(server.URL + "/registry.toml")
+	assertTestShipRegistry(t, cmdReg, err)
+}
@@ -0,0 +1,95 @@
+package router
+
+import (
+	"strings"
+)
+
+// FunctionHelp documents a registered function, or a group of functions
+// and the tree of functions nested under it.
+type FunctionHelp struct {
+	Name        string
+	Aliases     []string
+	Usage       string
+	Description string
+	Manual      string
+	// Functions are the functions nested under a group, sorted by name.
+	Functions []FunctionHelp
+}
+
+// IsGroup reports whether the help is for a group of functions.
+func (h FunctionHelp) IsGroup() bool {
+	return len(h.Functions) > 0
+}
+
+// Function returns the help of the function nested under the group with
+// the given name or alias.
+func (h FunctionHelp) Function(name string) (FunctionHelp, bool) {
+	return findFunctionHelp(h.Functions, name)
+}
+
+// CommandHelp documents a registered command and its functions, which are
+// sorted by name.
+type CommandHelp struct {
+	Command   string
+	Functions []FunctionHelp
+}
+
+// Function returns the help of the function with the given name or alias.
+func (h *CommandHelp) Function(name string) (FunctionHelp, bool) {
+	return findFunctionHelp(h.Functions, name)
+}
+
+// Help returns the documentation of a command's functions. The error is a
+// CommandNotFoundError if the command is not registered.
+func (cr *commandRegistry) Help(command string) (*CommandHelp, error) {
+	cmdRec, cmdRecOk := (*cr)[strings.ToLower(command)]
+	if !cmdRecOk {
+		return nil, CommandNotFoundError{Command: command}
+	}
+
+	return &CommandHelp{
+		Command:   strings.ToLower(command),
+		Functions: functionsHelp(cmdRec.Functions),
+	}, nil
+}
+
+// Help returns the documentation of a command's functions in the current
+// command registry.
+func (m *RegistryManager) Help(command string) (*CommandHelp, error) {
+	return m.Registry().Help(command)
+}
+
+func functionsHelp(functions functionRegistry) []FunctionHelp {
+	var help []FunctionHelp
+	for _, name := range functions.names() {
+		funcRec := functions[name]
+		help = append(help, FunctionHelp{
+			Name:        name,
+			Aliases:     funcRec.Aliases,
+			Usage:       funcRec.Usage,
+			Description: funcRec.Description,
+			Manual:      funcRec.Manual,
+			Functions:   functionsHelp(funcRec.Functions),
+		})
+	}
+
+	return help
+}
+
+func findFunctionHelp(functions []FunctionHelp, name string) (FunctionHelp, bool) {
+	name = strings.ToLower(name)
+	for _, funcHelp := range functions {
+		if funcHelp.Name == name {
+			return funcHelp, true
+		}
+	}
+	for _, funcHelp := range functions {
+		for _, alias := range funcHelp.Aliases {
+			if strings.ToLower(alias) == name {
+				return funcHelp, true
+			}
+		}
+	}
+
+	return FunctionHelp{}, false
+}
@@ -0,0 +1,61 @@
+package router
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCommandRegistryHelp(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromFormat([]byte(testRegYaml), FormatYAML)
+	assert.Nil(t, err)
+
+	help, err := cmdReg.Help("/DEPLOY")
+	assert.Nil(t, err)
+	assert.Equal(t, help.Command, "/deploy")
+	assert.Equal(t, help.Functions, []FunctionHelp{{
+		Name:        "ship",
+		Usage:       "/deploy ship <service>",
+		Description: "Ships a service.",
+	}})
+
+	funcHelp, ok := help.Function("Ship")
+	assert.True(t, ok)
+	assert.Equal(t, funcHelp.Usage, "/deploy ship <service>")
+
+	_, ok = help.Function("build")
+	assert.False(t, ok)
+}
+
+func TestCommandRegistryHelpCommandNotFound(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromFormat([]byte(testRegYaml), FormatYAML)
+	assert.Nil(t, err)
+
+	help, err := cmdReg.Help("/build")
+	assert.Nil(t, help)
+	var cmdErr CommandNotFoundError
+	assert.True(t, errors.As(err, &cmdErr))
+	assert.Equal(t, cmdErr.Command, "/build")
+}
+
+func TestCommandRegistryHelpNested(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromContents([]byte(testNestedRegJson))
+	assert.Nil(t, err)
+
+	help, err := cmdReg.Help("/ops")
+	assert.Nil(t, err)
+
+	db, ok := help.Function("db")
+	assert.True(t, ok)
+	assert.True(t, db.IsGroup())
+	assert.Equal(t, db.Description, "Database operations.")
+
+	backup, ok := db.Function("dump")
+	assert.True(t, ok)
+	assert.False(t, backup.IsGroup())
+	assert.Equal(t, backup.Name, "backup")
+
+	replica, ok := db.Function("replica")
+	assert.True(t, ok)
+	assert.Equal(t, replica.Functions[0].Usage, "/ops db replica promote <replica>")
+}
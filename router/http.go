@@ -0,0 +1,89 @@
+package router
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+)
+
+const (
+	errReadReqBodyFmt = "Could not read request body. Error: %v\n"
+	errWriteRespFmt   = "Could not write response body. Error: %v\n"
+)
+
+// HTTPRequestAdapter is a RequestAdapter that converts an *http.Request
+// into a task map. The request body is read in full.
+func HTTPRequestAdapter(req interface{}) TaskMap {
+	httpReq, ok := req.(*http.Request)
+	if !ok {
+		log.Printf(errUnknownEventFmt, req)
+		return TaskMap{}
+	}
+
+	task, err := newHTTPTask(httpReq)
+	if err != nil {
+		log.Printf(errReadReqBodyFmt, err)
+	}
+
+	return task
+}
+
+// ServeHTTP lets a Router be mounted on a net/http server. The task is
+// built from the incoming request, run through the handlers, and the
+// status code, response headers and body of the task are written back.
+// The router's own request and response adapters are not used, and the
+// request's context is made available to the handlers.
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	ctxMap, cancel := r.newContext(req.Context())
+	defer cancel()
+	task, err := newHTTPTask(req)
+	if err != nil {
+		log.Printf(errReadReqBodyFmt, err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	r.handler.Before(&ctxMap, &task)
+
+	for key, val := range responseHeaders(&task) {
+		w.Header().Set(key, val)
+	}
+	w.WriteHeader(responseStatusCode(&task))
+	if _, err := w.Write([]byte(responseBody(&task))); err != nil {
+		log.Printf(errWriteRespFmt, err)
+	}
+}
+
+func newHTTPTask(req *http.Request) (TaskMap, error) {
+	headers := make(map[string]string, len(req.Header))
+	for key, vals := range req.Header {
+		headers[key] = strings.Join(vals, ",")
+	}
+
+	query := make(map[string]string)
+	for key, vals := range req.URL.Query() {
+		if len(vals) > 0 {
+			query[key] = vals[0]
+		}
+	}
+
+	task := TaskMap{
+		TaskHeadersKey:    headers,
+		TaskQueryKey:      query,
+		TaskPathParamsKey: map[string]string{},
+		TaskBodyKey:       "",
+	}
+	if req.Body == nil {
+		return task, nil
+	}
+
+	defer req.Body.Close()
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return task, err
+	}
+	task[TaskBodyKey] = string(body)
+
+	return task, nil
+}
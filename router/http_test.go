@@ -0,0 +1,62 @@
+package router
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPRequestAdapter(t *testing.T) {
+	testReq := httptest.NewRequest(http.MethodPost, "/slack?q=1&q=2", strings.NewReader(testBody))
+	testReq.Header.Add("X-Test", "a")
+	testReq.Header.Add("X-Test", "b")
+
+	testTask := HTTPRequestAdapter(testReq)
+
+	assert.Equal(t, testTask[TaskBodyKey], testBody)
+	assert.Equal(t, testTask[TaskHeadersKey].(map[string]string)["X-Test"], "a,b")
+	assert.Equal(t, testTask[TaskQueryKey], map[string]string{"q": "1"})
+}
+
+func TestHTTPRequestAdapterUnknownType(t *testing.T) {
+	testTask := HTTPRequestAdapter(testBody)
+	assert.Empty(t, testTask)
+}
+
+func TestRouterServeHTTP(t *testing.T) {
+	mockHandler1 := new(mockHandler)
+	mockHandler1.On("Before", mock.Anything, mock.Anything).Return(false)
+	mockHandler1.On("After", mock.Anything, mock.Anything)
+	mockHandler1.On("Execute", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		task := args.Get(1).(*TaskMap)
+		assert.Equal(t, (*task)[TaskBodyKey], testBody)
+
+		(*task)[TaskStatusCodeKey] = http.StatusCreated
+		(*task)[TaskRespHeadersKey] = map[string]string{"Content-Type": "text/plain"}
+		(*task)[TaskBodyKey] = mRRespBody
+	})
+	unusedReqAdapter := func(interface{}) TaskMap {
+		t.Fatal("request adapter should not be used")
+		return nil
+	}
+	unusedRespAdapter := func(*TaskMap) interface{} {
+		t.Fatal("response adapter should not be used")
+		return nil
+	}
+
+	testRouter := NewRouter(unusedReqAdapter, unusedRespAdapter, mockHandler1)
+	testReq := httptest.NewRequest(http.MethodPost, "/slack", strings.NewReader(testBody))
+	testRec := httptest.NewRecorder()
+
+	testRouter.ServeHTTP(testRec, testReq)
+
+	assert.Equal(t, testRec.Code, http.StatusCreated)
+	assert.Equal(t, testRec.Header().Get("Content-Type"), "text/plain")
+	assert.Equal(t, testRec.Body.String(), mRRespBody)
+	mockHandler1.AssertNumberOfCalls(t, "Before", 1)
+	mockHandler1.AssertNumberOfCalls(t, "Execute", 1)
+	mockHandler1.AssertNumberOfCalls(t, "After", 1)
+}
@@ -0,0 +1,209 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"github.com/phoenixcoder/slack-golang-sdk/slashcmd"
+	"log"
+	"net/url"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	errRefreshRegFmt    = "Could not refresh registry, keeping last good version. Location: %s, Error: %v\n"
+	errUnknownRegLocFmt = "Registry location is neither a file nor a url. Location: %s"
+	logRegRefreshedFmt  = "Registry refreshed. Location: %s\n"
+)
+
+// RegistryManager keeps a command registry up to date by reloading it
+// from its file or url on an interval. Lookups always see a complete
+// registry, and the last good registry is kept when a reload fails.
+type RegistryManager struct {
+	location string
+	isUrl    bool
+	client   *RegistryHTTPClient
+	verify   VerifyOptions
+	registry atomic.Value
+
+	// refreshMu guards the fields used to detect changes at the location.
+	refreshMu sync.Mutex
+	modTime   time.Time
+	etag      string
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewRegistryManager is a factory method that loads the registry from a
+// file path or url, and reloads it every interval until Stop is called.
+// Files are only reloaded when their modification time changes, and urls
+// are requested with the ETag of the last response. An interval of zero
+// or less disables reloading. An error is returned if the first load
+// fails. Urls are downloaded with the DefaultRegistryHTTPOptions.
+func NewRegistryManager(location string, interval time.Duration) (*RegistryManager, error) {
+	return NewRegistryManagerWithClient(location, interval, NewRegistryHTTPClient(DefaultRegistryHTTPOptions()))
+}
+
+// NewRegistryManagerWithClient is a factory method like NewRegistryManager
+// that downloads urls with a separately configured client.
+func NewRegistryManagerWithClient(location string, interval time.Duration, client *RegistryHTTPClient) (*RegistryManager, error) {
+	return NewRegistryManagerWithVerification(location, interval, client, VerifyOptions{})
+}
+
+// NewRegistryManagerWithVerification is a factory method like
+// NewRegistryManagerWithClient that checks every version of the registry
+// it loads with the given options.
+func NewRegistryManagerWithVerification(location string, interval time.Duration, client *RegistryHTTPClient, opts VerifyOptions) (*RegistryManager, error) {
+	m := &RegistryManager{
+		location: location,
+		client:   client,
+		verify:   opts,
+		stop:     make(chan struct{}),
+	}
+
+	if _, err := os.Stat(location); err != nil {
+		parsedUrl, urlErr := url.Parse(location)
+		if urlErr != nil || (parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") {
+			return nil, fmt.Errorf(errUnknownRegLocFmt, location)
+		}
+		m.isUrl = true
+	}
+
+	if err := m.Refresh(); err != nil {
+		return nil, err
+	}
+
+	if interval > 0 {
+		go m.refreshEvery(interval)
+	}
+
+	return m, nil
+}
+
+// RegistrySnapshot is a command registry as it was at one moment. The
+// handlers of a request share one snapshot, so that the access lists they
+// check and the function they call come from the same registry even if it
+// is reloaded in between.
+type RegistrySnapshot interface {
+	GetFunctionRecord(cmd *slashcmd.Info) (*FunctionRecord, error)
+	AccessLists(cmd *slashcmd.Info) ([]*AccessList, error)
+	Help(command string) (*CommandHelp, error)
+	FunctionNames(command string) []string
+}
+
+// Registry returns the current command registry.
+func (m *RegistryManager) Registry() *commandRegistry {
+	return m.registry.Load().(*commandRegistry)
+}
+
+// Snapshot returns the current command registry, which is left as it is
+// by later reloads.
+func (m *RegistryManager) Snapshot() RegistrySnapshot {
+	return m.Registry()
+}
+
+// GetFunctionRecord looks up a function in the current command registry.
+func (m *RegistryManager) GetFunctionRecord(cmd *slashcmd.Info) (*FunctionRecord, error) {
+	return m.Registry().GetFunctionRecord(cmd)
+}
+
+// FunctionNames returns the sorted function names of a command in the
+// current command registry.
+func (m *RegistryManager) FunctionNames(command string) []string {
+	return m.Registry().FunctionNames(command)
+}
+
+// Refresh reloads the registry if it has changed at its location. The
+// current registry is only replaced if the new one loads successfully.
+func (m *RegistryManager) Refresh() error {
+	m.refreshMu.Lock()
+	defer m.refreshMu.Unlock()
+
+	var reg *commandRegistry
+	var err error
+	if m.isUrl {
+		reg, err = m.refreshFromUrl()
+	} else {
+		reg, err = m.refreshFromFile()
+	}
+	if err != nil {
+		return err
+	}
+
+	if reg != nil {
+		m.registry.Store(reg)
+		log.Printf(logRegRefreshedFmt, m.location)
+	}
+
+	return nil
+}
+
+// Stop ends the periodic reloading of the registry.
+func (m *RegistryManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stop)
+	})
+}
+
+func (m *RegistryManager) refreshEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.Refresh(); err != nil {
+				log.Printf(errRefreshRegFmt, m.location, err)
+			}
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// refreshFromFile returns a nil registry if the file has not been
+// modified since it was last loaded.
+func (m *RegistryManager) refreshFromFile() (*commandRegistry, error) {
+	info, err := os.Stat(m.location)
+	if err != nil {
+		return nil, err
+	}
+	if info.ModTime().Equal(m.modTime) {
+		return nil, nil
+	}
+
+	reg, err := NewCommandRegistryFromSourceWithVerification(NewFileSource(m.location), m.verify)
+	if err != nil {
+		return nil, err
+	}
+	m.modTime = info.ModTime()
+
+	return reg, nil
+}
+
+// refreshFromUrl returns a nil registry if the server reports the
+// registry has not been modified since it was last loaded.
+func (m *RegistryManager) refreshFromUrl() (*commandRegistry, error) {
+	ctx := context.Background()
+	dl, err := m.client.fetch(ctx, m.location, m.etag)
+	if err != nil {
+		return nil, err
+	}
+	if dl.notModified {
+		return nil, nil
+	}
+	if err := verifyRegistry(ctx, dl.contents, NewURLSourceWithClient(m.location, m.client), m.verify); err != nil {
+		return nil, err
+	}
+
+	reg, err := NewCommandRegistryFromFormat(dl.contents, formatFromResponse(dl.contentType, m.location))
+	if err != nil {
+		return nil, err
+	}
+	m.etag = dl.etag
+
+	return reg, nil
+}
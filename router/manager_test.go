@@ -0,0 +1,191 @@
+package router
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"github.com/phoenixcoder/slack-golang-sdk/slashcmd"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testManagerRegJson = `{
+           "command": {
+               "functions" : {
+                   "function1" : {
+                       "usage" : "usage1"
+                   }
+               }
+           }
+        }`
+	testManagerRegJson2 = `{
+           "command": {
+               "functions" : {
+                   "function2" : {
+                       "usage" : "usage2"
+                   }
+               }
+           }
+        }`
+	testEtag = `"v1"`
+)
+
+func writeTestRegistry(t *testing.T, path string, contents string, modTime time.Time) {
+	assert.Nil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	assert.Nil(t, os.Chtimes(path, modTime, modTime))
+}
+
+func TestRegistryManagerFile(t *testing.T) {
+	regPath := filepath.Join(t.TempDir(), "registry.json")
+	modTime := time.Now().Add(-time.Hour)
+	writeTestRegistry(t, regPath, testManagerRegJson, modTime)
+
+	testManager, err := NewRegistryManager(regPath, 0)
+	assert.Nil(t, err)
+	defer testManager.Stop()
+	assert.Equal(t, testManager.FunctionNames(testCommand), []string{"function1"})
+
+	// An unchanged modification time means the file is not reloaded.
+	writeTestRegistry(t, regPath, testManagerRegJson2, modTime)
+	assert.Nil(t, testManager.Refresh())
+	assert.Equal(t, testManager.FunctionNames(testCommand), []string{"function1"})
+
+	writeTestRegistry(t, regPath, testManagerRegJson2, modTime.Add(time.Minute))
+	assert.Nil(t, testManager.Refresh())
+	assert.Equal(t, testManager.FunctionNames(testCommand), []string{"function2"})
+
+	funcRec, err := testManager.GetFunctionRecord(&slashcmd.Info{
+		Command:   testCommand,
+		Arguments: []string{"function2"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, funcRec.Usage, "usage2")
+}
+
+func TestRegistryManagerKeepsLastGoodRegistry(t *testing.T) {
+	regPath := filepath.Join(t.TempDir(), "registry.json")
+	modTime := time.Now().Add(-time.Hour)
+	writeTestRegistry(t, regPath, testManagerRegJson, modTime)
+
+	testManager, err := NewRegistryManager(regPath, 0)
+	assert.Nil(t, err)
+	defer testManager.Stop()
+
+	writeTestRegistry(t, regPath, "{ malformed", modTime.Add(time.Minute))
+	assert.NotNil(t, testManager.Refresh())
+	assert.Equal(t, testManager.FunctionNames(testCommand), []string{"function1"})
+
+	assert.Nil(t, os.Remove(regPath))
+	assert.NotNil(t, testManager.Refresh())
+	assert.Equal(t, testManager.FunctionNames(testCommand), []string{"function1"})
+}
+
+func TestRegistryManagerSnapshot(t *testing.T) {
+	regPath := filepath.Join(t.TempDir(), "registry.json")
+	modTime := time.Now().Add(-time.Hour)
+	writeTestRegistry(t, regPath, testManagerRegJson, modTime)
+
+	testManager, err := NewRegistryManager(regPath, 0)
+	assert.Nil(t, err)
+	defer testManager.Stop()
+
+	snapshot := testManager.Snapshot()
+	writeTestRegistry(t, regPath, testManagerRegJson2, modTime.Add(time.Minute))
+	assert.Nil(t, testManager.Refresh())
+	assert.Equal(t, testManager.FunctionNames(testCommand), []string{"function2"})
+	assert.Equal(t, snapshot.FunctionNames(testCommand), []string{"function1"})
+}
+
+func TestRegistryManagerUrl(t *testing.T) {
+	var mu sync.Mutex
+	contents := testManagerRegJson
+	etag := testEtag
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests++
+		if r.Header.Get(ifNoneMatchHeader) == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set(etagHeader, etag)
+		w.Write([]byte(contents))
+	}))
+	defer server.Close()
+
+	testManager, err := NewRegistryManager(server.URL, 0)
+	assert.Nil(t, err)
+	defer testManager.Stop()
+	assert.Equal(t, testManager.FunctionNames(testCommand), []string{"function1"})
+
+	assert.Nil(t, testManager.Refresh())
+	assert.Equal(t, testManager.FunctionNames(testCommand), []string{"function1"})
+
+	mu.Lock()
+	contents = testManagerRegJson2
+	etag = `"v2"`
+	mu.Unlock()
+	assert.Nil(t, testManager.Refresh())
+	assert.Equal(t, testManager.FunctionNames(testCommand), []string{"function2"})
+	assert.Equal(t, requests, 3)
+}
+
+func TestRegistryManagerUrlErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not here", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	testManager, err := NewRegistryManager(server.URL, 0)
+	assert.Nil(t, testManager)
+	assert.NotNil(t, err)
+}
+
+func TestRegistryManagerPeriodicRefresh(t *testing.T) {
+	regPath := filepath.Join(t.TempDir(), "registry.json")
+	modTime := time.Now().Add(-time.Hour)
+	writeTestRegistry(t, regPath, testManagerRegJson, modTime)
+
+	testManager, err := NewRegistryManager(regPath, 10*time.Millisecond)
+	assert.Nil(t, err)
+	defer testManager.Stop()
+
+	writeTestRegistry(t, regPath, testManagerRegJson2, modTime.Add(time.Minute))
+	assert.Eventually(t, func() bool {
+		names := testManager.FunctionNames(testCommand)
+		return len(names) == 1 && names[0] == "function2"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRegistryManagerUnknownLocation(t *testing.T) {
+	testManager, err := NewRegistryManager("not/a/real/file.json", 0)
+	assert.Nil(t, testManager)
+	assert.NotNil(t, err)
+}
+
+func TestRegistryManagerUrlRequiresSignature(t *testing.T) {
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	assert.Nil(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/registry.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testManagerRegJson))
+	}))
+	defer server.Close()
+
+	testManager, err := NewRegistryManagerWithVerification(server.URL+"/registry.json", 0,
+		NewRegistryHTTPClient(DefaultRegistryHTTPOptions()), VerifyOptions{PublicKey: publicKey})
+	assert.Nil(t, testManager)
+	var verErr VerificationError
+	assert.True(t, errors.As(err, &verErr))
+}
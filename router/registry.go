@@ -2,14 +2,11 @@ package router
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"github.com/phoenixcoder/slack-golang-sdk/slashcmd"
-	"io/ioutil"
-	"log"
-	"net/http"
-	"os"
+	"sort"
 	"strings"
+	"time"
 )
 
 const (
@@ -19,19 +16,84 @@ const (
 	errDlRegFmt        = "Could not download registry contents. Error: %v\n"
 	errReadRegFmt      = "Could not read registry contents. Error: %v\n"
 	errFuncNotFoundFmt = "We're embarassed for you, but we don't know a '%s'."
+	errReservedFmt     = "'%s' is a reserved keyword, not a function."
 	regFileEnvVar      = "REGISTRY_FILE_PATH"
 	regUrlEnvVar       = "REGISTRY_URL"
+	contentTypeHeader  = "Content-Type"
 )
 
-type CommandNotFoundError error
-type FunctionNotFoundError error
-type ArgsNotFoundError error
+// CommandNotFoundError is returned when a command is not registered.
+type CommandNotFoundError struct {
+	// Command is the name of the command that was looked up.
+	Command string
+}
+
+func (e CommandNotFoundError) Error() string {
+	return fmt.Sprintf(cmdNotRegErrFmt, e.Command)
+}
+
+// FunctionNotFoundError is returned when a command is registered, but the
+// function named by one of its arguments is not.
+type FunctionNotFoundError struct {
+	// Command is the name of the command that was looked up, followed by
+	// the names of any groups walked.
+	Command string
+	// Function is the name of the function that was looked up.
+	Function string
+	// Functions are the sorted names of the command's known functions.
+	Functions []string
+	// Suggestions are the known functions the user may have meant, closest
+	// first.
+	Suggestions []string
+}
+
+func (e FunctionNotFoundError) Error() string {
+	return fmt.Sprintf(errFuncNotFoundFmt, e.Function)
+}
+
+// ArgsNotFoundError is returned when a command is registered, but no
+// arguments were given to name one of its functions, or the arguments ran
+// out on a group.
+type ArgsNotFoundError struct {
+	// Command is the name of the command that was looked up, followed by
+	// the names of any groups walked.
+	Command string
+	// Functions are the sorted names of the command's known functions.
+	Functions []string
+}
+
+func (e ArgsNotFoundError) Error() string {
+	return noArgsErrFmt
+}
+
+// ReservedKeywordError is returned when a command is registered, but an
+// argument naming a function is one of the command's reserved keywords.
+type ReservedKeywordError struct {
+	// Command is the name of the command that was looked up, followed by
+	// the names of any groups walked.
+	Command string
+	// Keyword is the lowercased keyword that was given.
+	Keyword string
+}
+
+func (e ReservedKeywordError) Error() string {
+	return fmt.Sprintf(errReservedFmt, e.Keyword)
+}
+
+// BuiltinKeywords are reserved by every command, in addition to the
+// command's own ReservedKeywords, unless the command or group defines a
+// function of the same name. The router answers them itself with the
+// command's help.
+var BuiltinKeywords = []string{"help", "list"}
+
 type commandRegistry map[string]commandRecord
-type functionRegistry map[string]functionRecord
+type functionRegistry map[string]FunctionRecord
 
 func (cr *commandRegistry) UnmarshalJSON(text []byte) error {
 	var tempMap map[string]commandRecord
-	json.Unmarshal(text, &tempMap)
+	if err := json.Unmarshal(text, &tempMap); err != nil {
+		return err
+	}
 
 	*cr = make(commandRegistry)
 	for key, val := range tempMap {
@@ -42,74 +104,289 @@ func (cr *commandRegistry) UnmarshalJSON(text []byte) error {
 }
 
 func (fr *functionRegistry) UnmarshalJSON(text []byte) error {
-	var tempMap map[string]functionRecord
-	json.Unmarshal(text, &tempMap)
+	var tempMap map[string]FunctionRecord
+	if err := json.Unmarshal(text, &tempMap); err != nil {
+		return err
+	}
 	*fr = make(functionRegistry)
 	for key, val := range tempMap {
-		(*fr)[strings.ToLower(key)] = val
+		val.Name = strings.ToLower(key)
+		(*fr)[val.Name] = val
 	}
 
 	return nil
 }
 
+// commandRecord describes a registered command. ReservedKeywords can not
+// name or alias a function; they are left for the router to handle.
+// Access limits who may use the command.
 type commandRecord struct {
 	ReservedKeywords []string         `json:"reservedKeywords"`
+	Access           *AccessList      `json:"access"`
 	Functions        functionRegistry `json:"functions"`
 }
 
-type functionRecord struct {
+// isReserved reports whether the name is a reserved keyword of the
+// command, or a built-in keyword that the functions beside it do not
+// define a function for.
+func (rec commandRecord) isReserved(name string, functions functionRegistry) bool {
+	for _, keyword := range rec.ReservedKeywords {
+		if strings.EqualFold(keyword, name) {
+			return true
+		}
+	}
+	if _, ok := functions[strings.ToLower(name)]; ok {
+		return false
+	}
+
+	return isBuiltinKeyword(name)
+}
+
+func isBuiltinKeyword(name string) bool {
+	for _, keyword := range BuiltinKeywords {
+		if strings.EqualFold(keyword, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// function looks up a function by its name or one of its aliases.
+func (fr functionRegistry) function(name string) (FunctionRecord, bool) {
+	name = strings.ToLower(name)
+	if funcRec, ok := fr[name]; ok {
+		return funcRec, true
+	}
+
+	for _, funcRec := range fr {
+		for _, alias := range funcRec.Aliases {
+			if strings.ToLower(alias) == name {
+				return funcRec, true
+			}
+		}
+	}
+
+	return FunctionRecord{}, false
+}
+
+// names returns the sorted names of the functions.
+func (fr functionRegistry) names() []string {
+	names := make([]string, 0, len(fr))
+	for name := range fr {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// FunctionRecord describes a single function that can be invoked through
+// a registered command, or a group of functions nested under it. Groups
+// are walked by the arguments that follow them, as in '/ops db backup',
+// and can not be invoked themselves.
+type FunctionRecord struct {
+	// Name is the lowercased name the function is registered under.
+	Name string `json:"-"`
+	// Path is the lowercased names of the groups walked to find the
+	// function, followed by its own name. It is set by GetFunctionRecord.
+	Path []string `json:"-"`
+	// Args are the arguments that followed the function's name. It is set
+	// by GetFunctionRecord.
+	Args []string `json:"-"`
+	// Functions are the functions nested under a group.
+	Functions functionRegistry `json:"functions"`
+	// Access limits who may use the function, or the functions nested
+	// under a group, in addition to the command's own access list.
+	Access *AccessList `json:"access"`
+	// Aliases are other names the function can be invoked by.
+	Aliases []string `json:"aliases"`
 	// Usage is a description of how to use the function with the command.
-	Usage string `json: "usage"`
+	// It is optional for groups.
+	Usage string `json:"usage"`
+	// Arguments are the positional arguments the function accepts, in
+	// order. Functions that declare no arguments or flags are passed their
+	// arguments unparsed.
+	Arguments []ArgumentSpec `json:"arguments"`
+	// Flags are the --flag arguments the function accepts.
+	Flags []ArgumentSpec `json:"flags"`
 	// Description is a description of what the function does.
-	Description string `json: "description"`
+	Description string `json:"description"`
 	// Manual is a location for additional information on the function.
-	Manual string `json: "manual"`
+	Manual string `json:"manual"`
+	// Url is the endpoint that serves the function.
+	Url string `json:"url"`
+	// Method is the HTTP method used to call the function. Defaults to POST.
+	Method string `json:"method"`
+	// Headers are added to every request sent to the function. Values may
+	// hold templates, such as "Bearer {env.DEPLOY_TOKEN}", where the
+	// environment variable is one the proxy allows.
+	Headers map[string]string `json:"headers"`
+	// ForwardHeaders are the headers of the incoming request that are
+	// forwarded to the function.
+	ForwardHeaders []string `json:"forwardHeaders"`
+	// Query is added to the query string of the function's url. Values
+	// may hold templates, such as "{user_id}" or "{args.service}".
+	Query map[string]string `json:"query"`
+	// BodyTransform shapes the slash command payload sent to the function.
+	// It is BodyForm, the default, or BodyJSON.
+	BodyTransform string `json:"bodyTransform"`
+	// Timeout bounds how long a call to the function may take.
+	Timeout Duration `json:"timeout"`
+	// ContentType is the content type of the request sent to the function.
+	ContentType string `json:"contentType"`
+	// Async functions are acknowledged straight away, and their response
+	// is posted to the slash command's response_url once they complete.
+	Async bool `json:"async"`
+	// Acknowledgement is the message an async function is acknowledged
+	// with.
+	Acknowledgement string `json:"acknowledgement"`
+}
+
+// The body transforms a function can ask for.
+const (
+	// BodyForm sends the slash command payload form encoded, as Slack
+	// sent it.
+	BodyForm = "form"
+	// BodyJSON sends the slash command payload as a JSON object of its
+	// form fields.
+	BodyJSON = "json"
+)
+
+// Duration is a time.Duration written in the registry as a string,
+// such as "1.5s" or "300ms".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(text []byte) error {
+	var durStr string
+	if err := json.Unmarshal(text, &durStr); err != nil {
+		return err
+	}
+
+	dur, err := time.ParseDuration(durStr)
+	if err != nil {
+		return err
+	}
+	*d = Duration(dur)
+
+	return nil
 }
 
-func (cr *commandRegistry) getFunctionRecord(cmd *slashcmd.Info) (*functionRecord, error) {
+// GetFunctionRecord looks up the function named, or aliased, by the
+// arguments of the given command, walking into a group of functions for
+// each argument that names one. An error is returned if the command is not
+// registered, the arguments run out before a function is found, an
+// argument is a reserved keyword, or a function is unknown to its command
+// or group.
+func (cr *commandRegistry) GetFunctionRecord(cmd *slashcmd.Info) (*FunctionRecord, error) {
 	cmdRec, cmdRecOk := (*cr)[strings.ToLower(cmd.Command)]
 	if !cmdRecOk {
-		return nil, CommandNotFoundError(fmt.Errorf(cmdNotRegErrFmt, cmd.Command))
+		return nil, CommandNotFoundError{Command: cmd.Command}
 	}
 
-	if len(cmd.Arguments) <= 0 {
-		return nil, ArgsNotFoundError(errors.New(noArgsErrFmt))
+	command := cmd.Command
+	functions := cmdRec.Functions
+	var path []string
+	for i := 0; ; i++ {
+		if i >= len(cmd.Arguments) {
+			return nil, ArgsNotFoundError{
+				Command:   command,
+				Functions: functions.names(),
+			}
+		}
+
+		funcName := cmd.Arguments[i]
+		if cmdRec.isReserved(funcName, functions) {
+			return nil, ReservedKeywordError{
+				Command: command,
+				Keyword: strings.ToLower(funcName),
+			}
+		}
+
+		funcRec, funcRecOk := functions.function(funcName)
+		if !funcRecOk {
+			funcNames := functions.names()
+			return nil, FunctionNotFoundError{
+				Command:     command,
+				Function:    funcName,
+				Functions:   funcNames,
+				Suggestions: SuggestFunctions(funcName, funcNames),
+			}
+		}
+
+		path = append(path, funcRec.Name)
+		if len(funcRec.Functions) == 0 {
+			funcRec.Path = path
+			funcRec.Args = append([]string{}, cmd.Arguments[i+1:]...)
+			return &funcRec, nil
+		}
+		command += " " + funcRec.Name
+		functions = funcRec.Functions
 	}
-	funcName := cmd.Arguments[0]
-	funcRec, funcRecOk := cmdRec.Functions[strings.ToLower(funcName)]
-	if !funcRecOk {
-		return nil, FunctionNotFoundError(fmt.Errorf(errFuncNotFoundFmt, funcName))
+}
+
+// Snapshot returns the command registry itself, as it does not change
+// once loaded.
+func (cr *commandRegistry) Snapshot() RegistrySnapshot {
+	return cr
+}
+
+// FunctionNames returns the sorted names of the functions registered under
+// the given command. It is empty if the command is not registered.
+func (cr *commandRegistry) FunctionNames(command string) []string {
+	cmdRec, cmdRecOk := (*cr)[strings.ToLower(command)]
+	if !cmdRecOk {
+		return []string{}
 	}
 
-	return &funcRec, nil
+	return cmdRec.Functions.names()
 }
 
+// NewCommandRegistry loads a registry from the location, trying it as a
+// file path and then as a url, before falling back to the locations in
+// the REGISTRY_FILE_PATH and REGISTRY_URL environment variables. Urls of
+// the form s3://bucket/key can not be loaded without a fetcher; see
+// NewCommandRegistryWithFetcher. The documents are not checked for
+// integrity; see NewCommandRegistryWithVerification. If no location can be
+// loaded, the SourceErrors returned say why each one failed.
 func NewCommandRegistry(location string) (*commandRegistry, error) {
-	reg, err := NewCommandRegistryFromFile(location)
-	if err == nil {
-		return reg, nil
-	}
+	return NewCommandRegistryWithFetcher(location, nil)
+}
 
-	reg, err = NewCommandRegistryFromUrl(location)
-	if err == nil {
-		return reg, nil
-	}
+// NewCommandRegistryWithFetcher behaves like NewCommandRegistry, fetching
+// s3://bucket/key urls with the given fetcher.
+func NewCommandRegistryWithFetcher(location string, fetcher ObjectFetcher) (*commandRegistry, error) {
+	return DefaultSourceChainWithFetcher(location, fetcher).Load()
+}
 
-	regFileEVLoc := os.Getenv(regFileEnvVar)
-	reg, err = NewCommandRegistryFromFile(regFileEVLoc)
-	if err == nil {
-		return reg, nil
-	}
+// DefaultSourceChain returns the chain of sources NewCommandRegistry tries
+// for the location.
+func DefaultSourceChain(location string) SourceChain {
+	return DefaultSourceChainWithFetcher(location, nil)
+}
+
+// DefaultSourceChainWithFetcher returns the chain of sources
+// NewCommandRegistryWithFetcher tries for the location.
+func DefaultSourceChainWithFetcher(location string, fetcher ObjectFetcher) SourceChain {
+	return NewSourceChain(
+		NewFileSource(location),
+		newRemoteSource(location, fetcher),
+		NewEnvSourceWithFetcher(regFileEnvVar, fetcher),
+		NewEnvSourceWithFetcher(regUrlEnvVar, fetcher),
+	)
+}
 
-	regUrlEVLoc := os.Getenv(regUrlEnvVar)
-	reg, err = NewCommandRegistryFromUrl(regUrlEVLoc)
-	if err == nil {
-		return reg, nil
+func newRemoteSource(location string, fetcher ObjectFetcher) RegistrySource {
+	if strings.HasPrefix(location, s3Scheme+"://") {
+		return NewObjectSource(location, fetcher)
 	}
 
-	return nil, fmt.Errorf("Failed Dynamic Loading of Registry. Location: %s, Error: %s\n", location, err.Error())
+	return NewURLSource(location)
 }
 
+// NewCommandRegistryFromContents loads a registry from a JSON document.
+// Only documents that can not be unmarshalled are refused; ValidateRegistry
+// checks a document more strictly.
 func NewCommandRegistryFromContents(contents []byte) (*commandRegistry, error) {
 	var cr commandRegistry
 	if err := json.Unmarshal(contents, &cr); err != nil {
@@ -119,34 +396,19 @@ func NewCommandRegistryFromContents(contents []byte) (*commandRegistry, error) {
 	return &cr, nil
 }
 
+// NewCommandRegistryFromFile loads a registry from a file. The format of
+// the file is detected from its extension: .yaml and .yml for YAML, .toml
+// for TOML, and JSON otherwise. NewCommandRegistryFromFileWithVerification
+// also checks its signature or digest.
 func NewCommandRegistryFromFile(fileLoc string) (*commandRegistry, error) {
-	if fileLoc == "" {
-		return nil, errors.New("File location must not be empty.")
-	}
-	contents, err := ioutil.ReadFile(fileLoc)
-	if err != nil {
-		return nil, err
-	}
-
-	return NewCommandRegistryFromContents(contents)
+	return NewCommandRegistryFromSource(NewFileSource(fileLoc))
 }
 
+// NewCommandRegistryFromUrl downloads a registry from a url. The format of
+// the registry is detected from the Content-Type of the response, or from
+// the extension of the url path when the content type does not name one.
+// NewCommandRegistryFromUrlWithVerification also checks its signature or
+// digest.
 func NewCommandRegistryFromUrl(url string) (*commandRegistry, error) {
-	if url == "" {
-		return nil, errors.New("Url must not be empty.")
-	}
-	log.Printf(logRegUrl, url)
-	resp, err := http.Get(url)
-	if err != nil {
-		log.Printf(errDlRegFmt, err)
-		return nil, err
-	}
-
-	contents, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf(errReadRegFmt, err)
-		return nil, err
-	}
-
-	return NewCommandRegistryFromContents(contents)
+	return NewCommandRegistryFromSource(NewURLSource(url))
 }
@@ -1,10 +1,13 @@
 package router
 
 import (
+	"errors"
 	"fmt"
 	"github.com/phoenixcoder/slack-golang-sdk/slashcmd"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+	"time"
 )
 
 const (
@@ -49,7 +52,7 @@ func TestGetFunctionRecord(t *testing.T) {
 			Arguments: []string{fmt.Sprintf(fmtStr, testFunctions, i)},
 		}
 		assert.Contains(t, (*cmdReg)[cmd.Command].ReservedKeywords, fmt.Sprintf(fmtStr, testResKeywords, i))
-		funcRec, err := cmdReg.getFunctionRecord(cmd)
+		funcRec, err := cmdReg.GetFunctionRecord(cmd)
 		assert.Nil(t, err)
 		assert.Equal(t, funcRec.Usage, fmt.Sprintf(fmtStr, testUsage, i))
 		assert.Equal(t, funcRec.Description, fmt.Sprintf(fmtStr, testDesc, i))
@@ -70,7 +73,7 @@ func TestCommandNotFoundError(t *testing.T) {
 	cmdReg := make(commandRegistry)
 
 	cmd := &slashcmd.Info{}
-	funcRec, err := cmdReg.getFunctionRecord(cmd)
+	funcRec, err := cmdReg.GetFunctionRecord(cmd)
 	assert.Nil(t, funcRec)
 	assert.NotNil(t, err)
 
@@ -82,10 +85,10 @@ func TestFunctionNotFoundError(t *testing.T) {
 	cmdReg, err := NewCommandRegistryFromContents([]byte(testRegJson))
 	assert.Nil(t, err)
 	cmd := &slashcmd.Info{
-		Command:   testCommand,
+		Command:   testCommand + "1",
 		Arguments: []string{testFunctions},
 	}
-	funcRec, err := cmdReg.getFunctionRecord(cmd)
+	funcRec, err := cmdReg.GetFunctionRecord(cmd)
 	assert.Nil(t, funcRec)
 	assert.NotNil(t, err)
 
@@ -94,15 +97,205 @@ func TestFunctionNotFoundError(t *testing.T) {
 }
 
 func TestArgsNotFoundError(t *testing.T) {
-	cmdReg := make(commandRegistry)
+	cmdReg, err := NewCommandRegistryFromContents([]byte(testRegJson))
+	assert.Nil(t, err)
 
 	cmd := &slashcmd.Info{
-		Command: testCommand,
+		Command: testCommand + "1",
 	}
-	funcRec, err := cmdReg.getFunctionRecord(cmd)
+	funcRec, err := cmdReg.GetFunctionRecord(cmd)
 	assert.Nil(t, funcRec)
 	assert.NotNil(t, err)
 
 	_, ok := err.(ArgsNotFoundError)
 	assert.True(t, ok)
 }
+
+func TestFunctionNames(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromContents([]byte(testRegJson))
+	assert.Nil(t, err)
+
+	assert.Equal(t, cmdReg.FunctionNames("COMMAND1"), []string{"functions1"})
+	assert.Equal(t, cmdReg.FunctionNames("command2"), []string{"functions2"})
+	assert.Empty(t, cmdReg.FunctionNames(testCommand))
+}
+
+func TestFunctionRecordEndpoint(t *testing.T) {
+	testEndpointJson := `{
+           "command": {
+               "functions" : {
+                   "function" : {
+                       "usage" : "usage",
+                       "url" : "https://example.com/function",
+                       "method" : "GET",
+                       "headers" : {"Authorization" : "Bearer token"},
+                       "timeout" : "1500ms",
+                       "contentType" : "application/json"
+                   }
+               }
+           }
+        }`
+	cmdReg, err := NewCommandRegistryFromContents([]byte(testEndpointJson))
+	assert.Nil(t, err)
+
+	funcRec, err := cmdReg.GetFunctionRecord(&slashcmd.Info{
+		Command:   testCommand,
+		Arguments: []string{"function"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, funcRec.Url, "https://example.com/function")
+	assert.Equal(t, funcRec.Method, "GET")
+	assert.Equal(t, funcRec.Headers["Authorization"], "Bearer token")
+	assert.Equal(t, time.Duration(funcRec.Timeout), 1500*time.Millisecond)
+	assert.Equal(t, funcRec.ContentType, "application/json")
+}
+
+func TestRegistryErrorDetails(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromContents([]byte(testRegJson))
+	assert.Nil(t, err)
+
+	_, err = cmdReg.GetFunctionRecord(&slashcmd.Info{Command: testCommand})
+	var cmdErr CommandNotFoundError
+	assert.True(t, errors.As(err, &cmdErr))
+	assert.Equal(t, cmdErr.Command, testCommand)
+
+	_, err = cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "Command1"})
+	var argsErr ArgsNotFoundError
+	assert.True(t, errors.As(err, &argsErr))
+	assert.Equal(t, argsErr.Command, "Command1")
+	assert.Equal(t, argsErr.Functions, []string{"functions1"})
+
+	_, err = cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "command1", Arguments: []string{testFunctions}})
+	var funcErr FunctionNotFoundError
+	assert.True(t, errors.As(err, &funcErr))
+	assert.Equal(t, funcErr.Command, "command1")
+	assert.Equal(t, funcErr.Function, testFunctions)
+	assert.Equal(t, funcErr.Functions, []string{"functions1"})
+
+	switch err.(type) {
+	case CommandNotFoundError, ArgsNotFoundError:
+		t.Errorf("Unexpected error type %T", err)
+	}
+}
+
+func TestGetFunctionRecordAlias(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromContents([]byte(`{
+           "/deploy": {
+               "reservedKeywords" : ["admin"],
+               "functions" : {
+                   "Ship" : {
+                       "usage" : "/deploy ship <service>",
+                       "aliases" : ["Release", "push"]
+                   }
+               }
+           }
+        }`))
+	assert.Nil(t, err)
+
+	for _, name := range []string{"ship", "release", "PUSH"} {
+		funcRec, err := cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "/deploy", Arguments: []string{name}})
+		assert.Nil(t, err, name)
+		assert.Equal(t, funcRec.Name, "ship")
+	}
+
+	for _, keyword := range []string{"help", "List", "admin"} {
+		_, err := cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "/deploy", Arguments: []string{keyword}})
+		var reservedErr ReservedKeywordError
+		assert.True(t, errors.As(err, &reservedErr), keyword)
+		assert.Equal(t, reservedErr.Keyword, strings.ToLower(keyword))
+	}
+}
+
+func TestGetFunctionRecordDefinedBuiltin(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromContents([]byte(`{
+           "/deploy": {
+               "reservedKeywords" : ["admin"],
+               "functions" : {
+                   "help" : {
+                       "usage" : "/deploy help"
+                   }
+               }
+           }
+        }`))
+	assert.Nil(t, err)
+
+	funcRec, err := cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "/deploy", Arguments: []string{"Help"}})
+	assert.Nil(t, err)
+	assert.Equal(t, funcRec.Name, "help")
+
+	for _, keyword := range []string{"list", "admin"} {
+		_, err := cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "/deploy", Arguments: []string{keyword}})
+		var reservedErr ReservedKeywordError
+		assert.True(t, errors.As(err, &reservedErr), keyword)
+	}
+}
+
+const testNestedRegJson = `{
+           "/ops": {
+               "functions" : {
+                   "db" : {
+                       "description" : "Database operations.",
+                       "functions" : {
+                           "backup" : {
+                               "usage" : "/ops db backup <database>",
+                               "aliases" : ["dump"]
+                           },
+                           "replica" : {
+                               "functions" : {
+                                   "promote" : {
+                                       "usage" : "/ops db replica promote <replica>"
+                                   }
+                               }
+                           }
+                       }
+                   },
+                   "status" : {
+                       "usage" : "/ops status"
+                   }
+               }
+           }
+        }`
+
+func TestGetFunctionRecordNested(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromContents([]byte(testNestedRegJson))
+	assert.Nil(t, err)
+
+	funcRec, err := cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "/ops", Arguments: []string{"DB", "dump", "orders", "--full"}})
+	assert.Nil(t, err)
+	assert.Equal(t, funcRec.Usage, "/ops db backup <database>")
+	assert.Equal(t, funcRec.Path, []string{"db", "backup"})
+	assert.Equal(t, funcRec.Args, []string{"orders", "--full"})
+
+	funcRec, err = cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "/ops", Arguments: []string{"db", "replica", "promote"}})
+	assert.Nil(t, err)
+	assert.Equal(t, funcRec.Path, []string{"db", "replica", "promote"})
+	assert.Empty(t, funcRec.Args)
+
+	funcRec, err = cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "/ops", Arguments: []string{"status", "db"}})
+	assert.Nil(t, err)
+	assert.Equal(t, funcRec.Path, []string{"status"})
+	assert.Equal(t, funcRec.Args, []string{"db"})
+}
+
+func TestGetFunctionRecordNestedErrors(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromContents([]byte(testNestedRegJson))
+	assert.Nil(t, err)
+
+	_, err = cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "/ops", Arguments: []string{"db"}})
+	var argsErr ArgsNotFoundError
+	assert.True(t, errors.As(err, &argsErr))
+	assert.Equal(t, argsErr.Command, "/ops db")
+	assert.Equal(t, argsErr.Functions, []string{"backup", "replica"})
+
+	_, err = cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "/ops", Arguments: []string{"db", "replica", "promot"}})
+	var funcErr FunctionNotFoundError
+	assert.True(t, errors.As(err, &funcErr))
+	assert.Equal(t, funcErr.Command, "/ops db replica")
+	assert.Equal(t, funcErr.Functions, []string{"promote"})
+	assert.Equal(t, funcErr.Suggestions, []string{"promote"})
+
+	_, err = cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "/ops", Arguments: []string{"db", "help"}})
+	var reservedErr ReservedKeywordError
+	assert.True(t, errors.As(err, &reservedErr))
+	assert.Equal(t, reservedErr.Command, "/ops db")
+}
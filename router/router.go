@@ -1,13 +1,45 @@
 package router
 
+import (
+	"context"
+	"time"
+)
+
+// The canonical task keys. Handlers and adapters should prefer the typed
+// accessors on TaskMap over reading these keys directly.
+const (
+	// TaskHeadersKey is the task key for the request headers, stored as a
+	// map[string]string.
+	TaskHeadersKey = "headers"
+	// TaskQueryKey is the task key for the query string parameters, stored
+	// as a map[string]string.
+	TaskQueryKey = "query"
+	// TaskPathParamsKey is the task key for the path parameters, stored as
+	// a map[string]string.
+	TaskPathParamsKey = "path-parameters"
+	// TaskBodyKey is the task key for the request or response body, stored
+	// as a string.
+	TaskBodyKey = "body"
+	// TaskStatusCodeKey is the task key for the response status code,
+	// stored as an int.
+	TaskStatusCodeKey = "StatusCode"
+	// TaskRespHeadersKey is the task key for the response headers, stored
+	// as a map[string]string.
+	TaskRespHeadersKey = "response-headers"
+	// TaskErrorKey is the task key for an error recorded by a handler,
+	// stored as an error.
+	TaskErrorKey = "error"
+)
+
 // Router manages a sequence of actions that occur to a request on its
 // way into the service, and to the response on its way out. The sequence
 // of actions are user-defined.
 type Router struct {
-	handler       Handler
-	createContext ContextCreator
-	adaptRequest  RequestAdapter
-	adaptResponse ResponseAdapter
+	handler        Handler
+	createContext  ContextCreator
+	adaptRequest   RequestAdapter
+	adaptResponse  ResponseAdapter
+	deadlineMargin time.Duration
 }
 
 // ContextCreator is a factory method that generates a context map
@@ -152,12 +184,46 @@ func (c *ChainHandler) After(context *ContextMap, task *TaskMap) {
 // context and task is then adapted into the expected response for
 // the caller.
 func (r *Router) Handle(req interface{}) interface{} {
-	context := r.createContext()
+	return r.HandleContext(context.Background(), req)
+}
+
+// HandleContext method behaves like Handle, but makes the given context
+// available to the handlers through the context map. If the context has
+// a deadline, the handlers see a deadline moved earlier by the router's
+// deadline margin, so that downstream calls are cancelled while there is
+// still time to respond.
+func (r *Router) HandleContext(ctx context.Context, req interface{}) interface{} {
+	ctxMap, cancel := r.newContext(ctx)
+	defer cancel()
 	task := r.adaptRequest(req)
-	r.handler.Before(&context, &task)
+	r.handler.Before(&ctxMap, &task)
 	return r.adaptResponse(&task)
 }
 
+// SetDeadlineMargin changes how long before the caller's deadline the
+// handlers' context is cancelled. The default is DefaultDeadlineMargin.
+func (r *Router) SetDeadlineMargin(margin time.Duration) {
+	r.deadlineMargin = margin
+}
+
+// newContext creates the context map for a request, with the given
+// context.Context made available in it. The cancel function returned
+// must be called once the request is handled.
+func (r *Router) newContext(ctx context.Context) (ContextMap, context.CancelFunc) {
+	cancel := context.CancelFunc(func() {})
+	if deadline, ok := ctx.Deadline(); ok {
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-r.deadlineMargin))
+	}
+
+	ctxMap := r.createContext()
+	if ctxMap == nil {
+		ctxMap = DefaultContextCreator()
+	}
+	ctxMap[RequestContextKey] = ctx
+
+	return ctxMap, cancel
+}
+
 // NewRouter is a factory method to create a Router pointer with a
 // default context creator, a given request and response adapter, and
 // a list of handlers.
@@ -177,9 +243,10 @@ func NewRouterWithContextCreator(ctxCreator ContextCreator,
 func newRouter(ctxCreator ContextCreator, requestAdapter RequestAdapter,
 	responseAdapter ResponseAdapter, handlers ...Handler) *Router {
 	return &Router{
-		handler:       NewChainHandler(handlers...),
-		createContext: ctxCreator,
-		adaptRequest:  requestAdapter,
-		adaptResponse: responseAdapter,
+		handler:        NewChainHandler(handlers...),
+		createContext:  ctxCreator,
+		adaptRequest:   requestAdapter,
+		adaptResponse:  responseAdapter,
+		deadlineMargin: DefaultDeadlineMargin,
 	}
 }
@@ -0,0 +1,70 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	s3Scheme         = "s3"
+	s3VersionIdParam = "versionId"
+	errS3LocationFmt = "Location is not an s3://bucket/key url. Location: '%s'"
+	errNoFetcherMsg  = "No object fetcher was given for s3 locations."
+)
+
+// ObjectLocation identifies an object in S3-compatible storage. An empty
+// VersionId refers to the latest version.
+type ObjectLocation struct {
+	Bucket    string
+	Key       string
+	VersionId string
+}
+
+// Object is an object fetched from S3-compatible storage.
+type Object struct {
+	Contents    []byte
+	ContentType string
+	VersionId   string
+	ETag        string
+}
+
+// ObjectFetcher is the interface that wraps the FetchObject method, which
+// fetches an object from S3-compatible storage. The s3fetch package
+// implements it with the AWS SDK.
+type ObjectFetcher interface {
+	FetchObject(ctx context.Context, loc ObjectLocation) (*Object, error)
+}
+
+// ParseObjectLocation parses a location of the form s3://bucket/key, with
+// an optional ?versionId= query parameter.
+func ParseObjectLocation(location string) (ObjectLocation, error) {
+	parsedUrl, err := url.Parse(location)
+	if err != nil || parsedUrl.Scheme != s3Scheme || parsedUrl.Host == "" {
+		return ObjectLocation{}, fmt.Errorf(errS3LocationFmt, location)
+	}
+
+	key := strings.TrimPrefix(parsedUrl.Path, "/")
+	if key == "" {
+		return ObjectLocation{}, fmt.Errorf(errS3LocationFmt, location)
+	}
+
+	return ObjectLocation{
+		Bucket:    parsedUrl.Host,
+		Key:       key,
+		VersionId: parsedUrl.Query().Get(s3VersionIdParam),
+	}, nil
+}
+
+// NewCommandRegistryFromObject loads a registry from an s3://bucket/key
+// location with the given fetcher. The format of the registry is detected
+// from the object's Content-Type, or from the extension of its key.
+func NewCommandRegistryFromObject(location string, fetcher ObjectFetcher) (*commandRegistry, error) {
+	if fetcher == nil {
+		return nil, errors.New(errNoFetcherMsg)
+	}
+
+	return NewCommandRegistryFromSource(NewObjectSource(location, fetcher))
+}
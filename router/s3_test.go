@@ -0,0 +1,108 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+const (
+	testBucket           = "registries"
+	testKey              = "slack/registry.yaml"
+	testObjectMissingFmt = "Object not found. Bucket: '%s', Key: '%s', Version: '%s'"
+)
+
+// memoryObjectFetcher is an in-memory stand-in for S3-compatible storage
+// that keeps every version of the objects put into it.
+type memoryObjectFetcher struct {
+	mu       sync.RWMutex
+	versions map[string][]Object
+}
+
+// newMemoryObjectFetcher is a factory method for creating an empty
+// in-memory fetcher.
+func newMemoryObjectFetcher() *memoryObjectFetcher {
+	return &memoryObjectFetcher{
+		versions: make(map[string][]Object),
+	}
+}
+
+// PutObject stores a new version of an object, and returns its version id.
+func (f *memoryObjectFetcher) PutObject(bucket string, key string, contents []byte, contentType string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	objKey := bucket + "/" + key
+	versionId := strconv.Itoa(len(f.versions[objKey]) + 1)
+	f.versions[objKey] = append(f.versions[objKey], Object{
+		Contents:    contents,
+		ContentType: contentType,
+		VersionId:   versionId,
+		ETag:        `"` + bucket + "/" + key + "/" + versionId + `"`,
+	})
+
+	return versionId
+}
+
+// FetchObject gets the latest or given version of an object.
+func (f *memoryObjectFetcher) FetchObject(ctx context.Context, loc ObjectLocation) (*Object, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	versions := f.versions[loc.Bucket+"/"+loc.Key]
+	for i := len(versions) - 1; i >= 0; i-- {
+		if loc.VersionId == "" || versions[i].VersionId == loc.VersionId {
+			obj := versions[i]
+			return &obj, nil
+		}
+	}
+
+	return nil, fmt.Errorf(testObjectMissingFmt, loc.Bucket, loc.Key, loc.VersionId)
+}
+
+func TestParseObjectLocation(t *testing.T) {
+	loc, err := ParseObjectLocation("s3://registries/slack/registry.yaml?versionId=abc")
+	assert.Nil(t, err)
+	assert.Equal(t, loc, ObjectLocation{Bucket: testBucket, Key: testKey, VersionId: "abc"})
+
+	for _, badLoc := range []string{"https://registries/slack.json", "s3:///slack.json", "s3://registries", "s3://registries/"} {
+		_, err := ParseObjectLocation(badLoc)
+		assert.NotNil(t, err, badLoc)
+	}
+}
+
+func TestNewCommandRegistryFromObjectVersions(t *testing.T) {
+	fetcher := newMemoryObjectFetcher()
+	firstVersion := fetcher.PutObject(testBucket, testKey, []byte(testRegYaml), "")
+	fetcher.PutObject(testBucket, testKey, []byte(testManagerRegJson), "application/json")
+
+	cmdReg, err := NewCommandRegistryFromObject("s3://registries/slack/registry.yaml?versionId="+firstVersion, fetcher)
+	assertTestShipRegistry(t, cmdReg, err)
+
+	cmdReg, err = NewCommandRegistryFromObject("s3://registries/slack/registry.yaml", fetcher)
+	assert.Nil(t, err)
+	assert.Equal(t, cmdReg.FunctionNames(testCommand), []string{"function1"})
+
+	cmdReg, err = NewCommandRegistryFromObject("s3://registries/slack/registry.yaml?versionId=99", fetcher)
+	assert.Nil(t, cmdReg)
+	assert.NotNil(t, err)
+
+	cmdReg, err = NewCommandRegistryFromObject("s3://registries/slack/registry.yaml", nil)
+	assert.Nil(t, cmdReg)
+	assert.NotNil(t, err)
+}
+
+func TestNewCommandRegistryFromS3Location(t *testing.T) {
+	fetcher := newMemoryObjectFetcher()
+	fetcher.PutObject(testBucket, testKey, []byte(testRegYaml), "")
+
+	cmdReg, err := NewCommandRegistryWithFetcher("s3://registries/slack/registry.yaml", fetcher)
+	assertTestShipRegistry(t, cmdReg, err)
+
+	cmdReg, err = NewCommandRegistry("s3://registries/slack/registry.yaml")
+	assert.Nil(t, cmdReg)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,58 @@
+// Package s3fetch fetches command registries from S3-compatible storage
+// with the AWS SDK. It is kept apart from the router package, so that only
+// the users who load registries from S3 depend on the SDK.
+package s3fetch
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"io/ioutil"
+)
+
+type getObjectAPI interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// ObjectFetcher fetches objects with an AWS SDK S3 client. Pointing the
+// client at another endpoint, such as a MinIO server, lets it fetch from
+// any S3-compatible storage.
+type ObjectFetcher struct {
+	client getObjectAPI
+}
+
+// NewObjectFetcher is a factory method for creating a fetcher with a
+// separately configured S3 client.
+func NewObjectFetcher(client getObjectAPI) *ObjectFetcher {
+	return &ObjectFetcher{client: client}
+}
+
+// FetchObject gets the object, or the given version of it, from its bucket.
+func (f *ObjectFetcher) FetchObject(ctx context.Context, loc router.ObjectLocation) (*router.Object, error) {
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(loc.Bucket),
+		Key:    aws.String(loc.Key),
+	}
+	if loc.VersionId != "" {
+		input.VersionId = aws.String(loc.VersionId)
+	}
+
+	output, err := f.client.GetObject(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	defer output.Body.Close()
+
+	contents, err := ioutil.ReadAll(output.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &router.Object{
+		Contents:    contents,
+		ContentType: aws.ToString(output.ContentType),
+		VersionId:   aws.ToString(output.VersionId),
+		ETag:        aws.ToString(output.ETag),
+	}, nil
+}
@@ -0,0 +1,62 @@
+package s3fetch
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/phoenixcoder/serverless-request-router/router"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	testBucket  = "registries"
+	testKey     = "slack/registry.yaml"
+	testRegYaml = `
+/deploy:
+  functions:
+    ship:
+      usage: /deploy ship <service>
+`
+)
+
+func TestObjectFetcher(t *testing.T) {
+	var requestedVersion string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+testBucket+"/"+testKey {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		requestedVersion = r.URL.Query().Get("versionId")
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("x-amz-version-id", "v2")
+		w.Write([]byte(testRegYaml))
+	}))
+	defer server.Close()
+
+	client := s3.New(s3.Options{
+		BaseEndpoint: aws.String(server.URL),
+		UsePathStyle: true,
+		Region:       "us-east-1",
+		Credentials:  aws.AnonymousCredentials{},
+	})
+	fetcher := NewObjectFetcher(client)
+
+	obj, err := fetcher.FetchObject(context.Background(), router.ObjectLocation{Bucket: testBucket, Key: testKey, VersionId: "v2"})
+	assert.Nil(t, err)
+	assert.Equal(t, requestedVersion, "v2")
+	assert.Equal(t, string(obj.Contents), testRegYaml)
+	assert.Equal(t, obj.ContentType, "application/yaml")
+	assert.Equal(t, obj.VersionId, "v2")
+	assert.Equal(t, obj.ETag, `"etag"`)
+
+	cmdReg, err := router.NewCommandRegistryFromObject("s3://registries/slack/registry.yaml", fetcher)
+	assert.Nil(t, err)
+	assert.Equal(t, cmdReg.FunctionNames("/deploy"), []string{"ship"})
+
+	_, err = fetcher.FetchObject(context.Background(), router.ObjectLocation{Bucket: testBucket, Key: "missing.json"})
+	assert.NotNil(t, err)
+}
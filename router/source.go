@@ -0,0 +1,306 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	errEmptyFileLocMsg = "File location must not be empty."
+	errEmptyUrlMsg     = "Url must not be empty."
+	errEnvUnsetFmt     = "Environment variable is not set: '%s'"
+	errNoSourcesMsg    = "No registry sources were given."
+	errAllSourcesMsg   = "Could not load registry from any source:"
+)
+
+// RegistrySource is the interface that wraps the Fetch method, which gets
+// a registry document and the format it is written in. String describes
+// the source in errors and logs.
+type RegistrySource interface {
+	Fetch(ctx context.Context) ([]byte, RegistryFormat, error)
+	String() string
+}
+
+// FileSource reads a registry from a file. The format of the file is
+// detected from its extension.
+type FileSource struct {
+	Path string
+}
+
+// NewFileSource is a factory method for creating a source for the file
+// at the given path.
+func NewFileSource(path string) FileSource {
+	return FileSource{Path: path}
+}
+
+// Fetch reads the file.
+func (s FileSource) Fetch(ctx context.Context) ([]byte, RegistryFormat, error) {
+	if s.Path == "" {
+		return nil, "", errors.New(errEmptyFileLocMsg)
+	}
+	contents, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return contents, FormatFromPath(s.Path), nil
+}
+
+func (s FileSource) String() string {
+	return fmt.Sprintf("file '%s'", s.Path)
+}
+
+// URLSource downloads a registry from a url. The format of the registry is
+// detected from the Content-Type of the response, or from the extension of
+// the url path when the content type does not name one. A client with the
+// DefaultRegistryHTTPOptions is used when Client is nil.
+type URLSource struct {
+	Url    string
+	Client *RegistryHTTPClient
+}
+
+// NewURLSource is a factory method for creating a source for the given url.
+func NewURLSource(url string) URLSource {
+	return URLSource{Url: url}
+}
+
+// NewURLSourceWithClient is a factory method for creating a source for the
+// given url that downloads it with a separately configured client.
+func NewURLSourceWithClient(url string, client *RegistryHTTPClient) URLSource {
+	return URLSource{Url: url, Client: client}
+}
+
+// Fetch downloads the registry.
+func (s URLSource) Fetch(ctx context.Context) ([]byte, RegistryFormat, error) {
+	if s.Url == "" {
+		return nil, "", errors.New(errEmptyUrlMsg)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = NewRegistryHTTPClient(DefaultRegistryHTTPOptions())
+	}
+
+	dl, err := client.fetch(ctx, s.Url, "")
+	if err != nil {
+		return nil, "", err
+	}
+
+	return dl.contents, formatFromResponse(dl.contentType, s.Url), nil
+}
+
+func (s URLSource) String() string {
+	return fmt.Sprintf("url '%s'", s.Url)
+}
+
+// ObjectSource fetches a registry from an s3://bucket/key location with
+// its Fetcher, which must be set.
+type ObjectSource struct {
+	Location string
+	Fetcher  ObjectFetcher
+}
+
+// NewObjectSource is a factory method for creating a source for an
+// s3://bucket/key location.
+func NewObjectSource(location string, fetcher ObjectFetcher) ObjectSource {
+	return ObjectSource{Location: location, Fetcher: fetcher}
+}
+
+// Fetch gets the object. The format of the registry is detected from the
+// object's Content-Type, or from the extension of its key.
+func (s ObjectSource) Fetch(ctx context.Context) ([]byte, RegistryFormat, error) {
+	if s.Fetcher == nil {
+		return nil, "", errors.New(errNoFetcherMsg)
+	}
+
+	loc, err := ParseObjectLocation(s.Location)
+	if err != nil {
+		return nil, "", err
+	}
+
+	obj, err := s.Fetcher.FetchObject(ctx, loc)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return obj.Contents, formatFromResponse(obj.ContentType, loc.Key), nil
+}
+
+func (s ObjectSource) String() string {
+	return fmt.Sprintf("object '%s'", s.Location)
+}
+
+// EnvSource loads a registry from the location held in an environment
+// variable. The variable is read on every Fetch, and its value is treated
+// like the argument to NewLocationSourceWithFetcher, with Fetcher used for
+// s3:// locations.
+type EnvSource struct {
+	Name    string
+	Fetcher ObjectFetcher
+}
+
+// NewEnvSource is a factory method for creating a source for the location
+// in the named environment variable.
+func NewEnvSource(name string) EnvSource {
+	return NewEnvSourceWithFetcher(name, nil)
+}
+
+// NewEnvSourceWithFetcher is a factory method for creating a source for
+// the location in the named environment variable, which fetches s3://
+// locations with the given fetcher.
+func NewEnvSourceWithFetcher(name string, fetcher ObjectFetcher) EnvSource {
+	return EnvSource{Name: name, Fetcher: fetcher}
+}
+
+// Fetch reads the variable and fetches the registry from its location.
+func (s EnvSource) Fetch(ctx context.Context) ([]byte, RegistryFormat, error) {
+	location := os.Getenv(s.Name)
+	if location == "" {
+		return nil, "", fmt.Errorf(errEnvUnsetFmt, s.Name)
+	}
+
+	return NewLocationSourceWithFetcher(location, s.Fetcher).Fetch(ctx)
+}
+
+func (s EnvSource) String() string {
+	return fmt.Sprintf("env %s", s.Name)
+}
+
+// MemorySource serves a registry document held in memory, along with its
+// detached signature if it has one.
+type MemorySource struct {
+	Contents  []byte
+	Format    RegistryFormat
+	Signature []byte
+}
+
+// NewMemorySource is a factory method for creating a source for a document
+// in the given format.
+func NewMemorySource(contents []byte, format RegistryFormat) MemorySource {
+	return MemorySource{Contents: contents, Format: format}
+}
+
+// Fetch returns the document.
+func (s MemorySource) Fetch(ctx context.Context) ([]byte, RegistryFormat, error) {
+	return s.Contents, s.Format, nil
+}
+
+func (s MemorySource) String() string {
+	return fmt.Sprintf("memory (%s)", s.Format)
+}
+
+// NewLocationSource picks a source for the location by its scheme:
+// s3:// locations are objects, http:// and https:// locations are urls,
+// and anything else is a file path.
+func NewLocationSource(location string) RegistrySource {
+	return NewLocationSourceWithFetcher(location, nil)
+}
+
+// NewLocationSourceWithFetcher behaves like NewLocationSource, fetching
+// s3:// locations with the given fetcher.
+func NewLocationSourceWithFetcher(location string, fetcher ObjectFetcher) RegistrySource {
+	switch {
+	case strings.HasPrefix(location, s3Scheme+"://"):
+		return NewObjectSource(location, fetcher)
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		return NewURLSource(location)
+	default:
+		return NewFileSource(location)
+	}
+}
+
+// NewCommandRegistryFromSource fetches a registry from the source, and
+// then loads it like NewCommandRegistryFromFormat. Documents are only
+// checked if the source is a VerifiedSource.
+func NewCommandRegistryFromSource(src RegistrySource) (*commandRegistry, error) {
+	return NewCommandRegistryFromSourceContext(context.Background(), src)
+}
+
+// NewCommandRegistryFromSourceContext behaves like
+// NewCommandRegistryFromSource, fetching the registry within the given
+// context.
+func NewCommandRegistryFromSourceContext(ctx context.Context, src RegistrySource) (*commandRegistry, error) {
+	contents, format, err := src.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCommandRegistryFromFormat(contents, format)
+}
+
+// SourceError records why a registry could not be loaded from a source.
+type SourceError struct {
+	// Source describes the source that failed.
+	Source string
+	// Err is the error fetching or loading the registry.
+	Err error
+}
+
+func (e SourceError) Error() string {
+	return e.Source + ": " + e.Err.Error()
+}
+
+func (e SourceError) Unwrap() error {
+	return e.Err
+}
+
+// SourceErrors is returned when no source in a chain could be loaded. It
+// has an entry for every source tried, in order.
+type SourceErrors []SourceError
+
+func (e SourceErrors) Error() string {
+	if len(e) == 0 {
+		return errNoSourcesMsg
+	}
+
+	msgs := make([]string, len(e))
+	for i, srcErr := range e {
+		msgs[i] = "  " + strings.ReplaceAll(srcErr.Error(), "\n", "\n    ")
+	}
+
+	return errAllSourcesMsg + "\n" + strings.Join(msgs, "\n")
+}
+
+func (e SourceErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, srcErr := range e {
+		errs[i] = srcErr
+	}
+
+	return errs
+}
+
+// SourceChain is an ordered list of sources to load a registry from.
+type SourceChain []RegistrySource
+
+// NewSourceChain is a factory method for creating a chain that tries the
+// sources in the order given.
+func NewSourceChain(sources ...RegistrySource) SourceChain {
+	return SourceChain(sources)
+}
+
+// Load returns the registry from the first source that can be fetched and
+// holds a valid document. If none can, it returns SourceErrors saying why
+// each source failed.
+func (c SourceChain) Load() (*commandRegistry, error) {
+	return c.LoadContext(context.Background())
+}
+
+// LoadContext behaves like Load, fetching from the sources within the
+// given context.
+func (c SourceChain) LoadContext(ctx context.Context) (*commandRegistry, error) {
+	errs := make(SourceErrors, 0, len(c))
+	for _, src := range c {
+		reg, err := NewCommandRegistryFromSourceContext(ctx, src)
+		if err == nil {
+			return reg, nil
+		}
+		errs = append(errs, SourceError{Source: src.String(), Err: err})
+	}
+
+	return nil, errs
+}
@@ -0,0 +1,123 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+type failingSource struct {
+	err error
+}
+
+func (s failingSource) Fetch(ctx context.Context) ([]byte, RegistryFormat, error) {
+	return nil, "", s.err
+}
+
+func (s failingSource) String() string {
+	return "failing"
+}
+
+func TestSourceChainLoadsFirstValidSource(t *testing.T) {
+	chain := NewSourceChain(
+		failingSource{err: errors.New("unreachable")},
+		NewMemorySource([]byte(`{"/deploy": []}`), FormatJSON),
+		NewMemorySource([]byte(testRegYaml), FormatYAML),
+		NewMemorySource([]byte(testManagerRegJson), FormatJSON),
+	)
+
+	cmdReg, err := chain.Load()
+	assertTestShipRegistry(t, cmdReg, err)
+}
+
+func TestSourceChainAggregatesErrors(t *testing.T) {
+	fetchErr := errors.New("unreachable")
+	chain := NewSourceChain(
+		failingSource{err: fetchErr},
+		NewFileSource(""),
+		NewMemorySource([]byte(`{"/deploy": []}`), FormatJSON),
+	)
+
+	cmdReg, err := chain.Load()
+	assert.Nil(t, cmdReg)
+
+	var srcErrs SourceErrors
+	assert.True(t, errors.As(err, &srcErrs))
+	assert.Len(t, srcErrs, 3)
+	assert.Equal(t, srcErrs[0].Source, "failing")
+	assert.Equal(t, srcErrs[0].Err, fetchErr)
+	assert.Equal(t, srcErrs[1].Source, "file ''")
+	assert.Equal(t, srcErrs[1].Err.Error(), errEmptyFileLocMsg)
+	assert.Equal(t, srcErrs[2].Source, "memory (json)")
+	assert.True(t, errors.Is(err, fetchErr))
+
+	var jsonErr *json.UnmarshalTypeError
+	assert.True(t, errors.As(err, &jsonErr))
+
+	assert.Equal(t, err.Error(), errAllSourcesMsg+"\n"+
+		"  failing: unreachable\n"+
+		"  file '': "+errEmptyFileLocMsg+"\n"+
+		"  memory (json): "+jsonErr.Error())
+}
+
+func TestEmptySourceChain(t *testing.T) {
+	cmdReg, err := NewSourceChain().Load()
+	assert.Nil(t, cmdReg)
+	assert.Equal(t, err.Error(), errNoSourcesMsg)
+}
+
+func TestEnvSource(t *testing.T) {
+	regPath := filepath.Join(t.TempDir(), "registry.yaml")
+	assert.Nil(t, ioutil.WriteFile(regPath, []byte(testRegYaml), 0644))
+
+	src := NewEnvSource(regFileEnvVar)
+	t.Setenv(regFileEnvVar, "")
+	_, _, err := src.Fetch(context.Background())
+	assert.Equal(t, err.Error(), "Environment variable is not set: '"+regFileEnvVar+"'")
+
+	t.Setenv(regFileEnvVar, regPath)
+	cmdReg, err := NewCommandRegistryFromSource(src)
+	assertTestShipRegistry(t, cmdReg, err)
+}
+
+func TestNewLocationSource(t *testing.T) {
+	assert.Equal(t, NewLocationSource("registry.json"), NewFileSource("registry.json"))
+	assert.Equal(t, NewLocationSource("https://example.com/registry.json"), NewURLSource("https://example.com/registry.json"))
+	assert.Equal(t, NewLocationSource("s3://registries/registry.json"), NewObjectSource("s3://registries/registry.json", nil))
+}
+
+func TestNewCommandRegistryReportsEverySource(t *testing.T) {
+	t.Setenv(regFileEnvVar, "")
+	t.Setenv(regUrlEnvVar, "")
+
+	cmdReg, err := NewCommandRegistry("")
+	assert.Nil(t, cmdReg)
+
+	var srcErrs SourceErrors
+	assert.True(t, errors.As(err, &srcErrs))
+	assert.Len(t, srcErrs, 4)
+	assert.Equal(t, srcErrs[0].Source, "file ''")
+	assert.Equal(t, srcErrs[1].Source, "url ''")
+	assert.Equal(t, srcErrs[2].Source, "env "+regFileEnvVar)
+	assert.Equal(t, srcErrs[3].Source, "env "+regUrlEnvVar)
+}
+
+func TestNewCommandRegistryFallsBackToEnvUrl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write([]byte(testRegYaml))
+	}))
+	defer server.Close()
+
+	t.Setenv(regFileEnvVar, "")
+	t.Setenv(regUrlEnvVar, server.URL)
+
+	cmdReg, err := NewCommandRegistry(filepath.Join(t.TempDir(), "missing.json"))
+	assertTestShipRegistry(t, cmdReg, err)
+}
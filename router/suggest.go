@@ -0,0 +1,100 @@
+package router
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	maxSuggestions   = 3
+	minPrefixLen     = 2
+	typosPerNameLen  = 3
+	minTyposAllowed  = 1
+	prefixSuggestion = -1
+)
+
+// SuggestFunctions returns the names, from those given, that the name may
+// have been a mistyping or abbreviation of. A name suggests the names it
+// is a prefix of, and the names within a few edits of it, a larger number
+// for longer names. At most three suggestions are returned, prefixes
+// first and then closest first.
+func SuggestFunctions(name string, names []string) []string {
+	name = strings.ToLower(name)
+	maxDist := len(name) / typosPerNameLen
+	if maxDist < minTyposAllowed {
+		maxDist = minTyposAllowed
+	}
+
+	type suggestion struct {
+		name string
+		dist int
+	}
+	var suggestions []suggestion
+	for _, candidate := range names {
+		lowerCandidate := strings.ToLower(candidate)
+		if lowerCandidate == name {
+			continue
+		}
+		if len(name) >= minPrefixLen && strings.HasPrefix(lowerCandidate, name) {
+			suggestions = append(suggestions, suggestion{candidate, prefixSuggestion})
+			continue
+		}
+		if dist := editDistance(name, lowerCandidate); dist <= maxDist {
+			suggestions = append(suggestions, suggestion{candidate, dist})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].dist != suggestions[j].dist {
+			return suggestions[i].dist < suggestions[j].dist
+		}
+		return suggestions[i].name < suggestions[j].name
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+
+	suggested := make([]string, len(suggestions))
+	for i, s := range suggestions {
+		suggested[i] = s.name
+	}
+
+	return suggested
+}
+
+// editDistance is the optimal string alignment distance between two
+// strings: the fewest single character insertions, deletions,
+// substitutions and swaps of adjacent characters that turn one into the
+// other.
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prevPrev := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] && prevPrev[j-2]+1 < curr[j] {
+				curr[j] = prevPrev[j-2] + 1
+			}
+		}
+		prevPrev, prev, curr = prev, curr, prevPrev
+	}
+
+	return prev[len(rb)]
+}
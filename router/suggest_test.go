@@ -0,0 +1,40 @@
+package router
+
+import (
+	"github.com/phoenixcoder/slack-golang-sdk/slashcmd"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, editDistance("", ""), 0)
+	assert.Equal(t, editDistance("ship", ""), 4)
+	assert.Equal(t, editDistance("ship", "ship"), 0)
+	assert.Equal(t, editDistance("shp", "ship"), 1)
+	assert.Equal(t, editDistance("sihp", "ship"), 1)
+	assert.Equal(t, editDistance("ca", "abc"), 3)
+	assert.Equal(t, editDistance("kitten", "sitting"), 3)
+}
+
+func TestSuggestFunctions(t *testing.T) {
+	names := []string{"deploy", "describe", "destroy", "rollback", "ship", "status"}
+
+	assert.Equal(t, SuggestFunctions("shp", names), []string{"ship"})
+	assert.Equal(t, SuggestFunctions("de", names), []string{"deploy", "describe", "destroy"})
+	assert.Equal(t, SuggestFunctions("ROLBACK", names), []string{"rollback"})
+	assert.Equal(t, SuggestFunctions("dstroy", names), []string{"destroy"})
+	assert.Equal(t, SuggestFunctions("statu", names), []string{"status"})
+	assert.Empty(t, SuggestFunctions("launch", names))
+	assert.Empty(t, SuggestFunctions("x", names))
+	assert.Empty(t, SuggestFunctions("ship", names))
+}
+
+func TestFunctionNotFoundSuggestions(t *testing.T) {
+	cmdReg, err := NewCommandRegistryFromFormat([]byte(testRegYaml), FormatYAML)
+	assert.Nil(t, err)
+
+	_, err = cmdReg.GetFunctionRecord(&slashcmd.Info{Command: "/deploy", Arguments: []string{"shpi"}})
+	funcErr, ok := err.(FunctionNotFoundError)
+	assert.True(t, ok)
+	assert.Equal(t, funcErr.Suggestions, []string{"ship"})
+}
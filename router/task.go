@@ -0,0 +1,153 @@
+package router
+
+import (
+	"strings"
+	"time"
+)
+
+// StatusCode returns the response status code, and whether one has been
+// set.
+func (t *TaskMap) StatusCode() (int, bool) {
+	statusCode, ok := t.get(TaskStatusCodeKey).(int)
+	return statusCode, ok
+}
+
+// SetStatusCode sets the response status code.
+func (t *TaskMap) SetStatusCode(statusCode int) {
+	t.set(TaskStatusCodeKey, statusCode)
+}
+
+// Body returns the body of the task. Before a handler replaces it, this
+// is the request body.
+func (t *TaskMap) Body() string {
+	body, _ := t.get(TaskBodyKey).(string)
+	return body
+}
+
+// SetBody sets the body of the task.
+func (t *TaskMap) SetBody(body string) {
+	t.set(TaskBodyKey, body)
+}
+
+// Headers returns the request headers.
+func (t *TaskMap) Headers() map[string]string {
+	headers, _ := t.get(TaskHeadersKey).(map[string]string)
+	return headers
+}
+
+// Header returns the value of the named request header. The name is
+// matched regardless of the casing used by the request adapter.
+func (t *TaskMap) Header(name string) string {
+	return lookupHeader(t.Headers(), name)
+}
+
+// ResponseHeaders returns the response headers.
+func (t *TaskMap) ResponseHeaders() map[string]string {
+	headers, _ := t.get(TaskRespHeadersKey).(map[string]string)
+	return headers
+}
+
+// SetResponseHeader sets a response header, replacing any value already
+// set for the name.
+func (t *TaskMap) SetResponseHeader(name string, value string) {
+	headers := t.ResponseHeaders()
+	if headers == nil {
+		headers = make(map[string]string)
+		t.set(TaskRespHeadersKey, headers)
+	}
+
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			delete(headers, key)
+		}
+	}
+	headers[name] = value
+}
+
+// Err returns the error a handler recorded while processing the task.
+func (t *TaskMap) Err() error {
+	err, _ := t.get(TaskErrorKey).(error)
+	return err
+}
+
+// SetErr records an error on the task.
+func (t *TaskMap) SetErr(err error) {
+	t.set(TaskErrorKey, err)
+}
+
+func (t *TaskMap) get(key string) interface{} {
+	if t == nil {
+		return nil
+	}
+
+	return (*t)[key]
+}
+
+func (t *TaskMap) set(key string, val interface{}) {
+	if t == nil {
+		return
+	}
+	if *t == nil {
+		*t = make(TaskMap)
+	}
+
+	(*t)[key] = val
+}
+
+// String returns the string stored under the key, and whether one was
+// found.
+func (c *ContextMap) String(key string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	val, ok := (*c)[key].(string)
+	return val, ok
+}
+
+// StringMap returns the map of strings stored under the key, or nil if
+// there is none.
+func (c *ContextMap) StringMap(key string) map[string]string {
+	if c == nil {
+		return nil
+	}
+
+	val, _ := (*c)[key].(map[string]string)
+	return val
+}
+
+// Strings returns the slice of strings stored under the key, or nil if
+// there is none.
+func (c *ContextMap) Strings(key string) []string {
+	if c == nil {
+		return nil
+	}
+
+	val, _ := (*c)[key].([]string)
+	return val
+}
+
+// Duration returns the duration stored under the key, or zero if there
+// is none.
+func (c *ContextMap) Duration(key string) time.Duration {
+	if c == nil {
+		return 0
+	}
+
+	val, _ := (*c)[key].(time.Duration)
+	return val
+}
+
+func lookupHeader(headers map[string]string, name string) string {
+	if val, ok := headers[name]; ok {
+		return val
+	}
+
+	for key, val := range headers {
+		if strings.EqualFold(key, name) {
+			return val
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,88 @@
+package router
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTaskMapAccessors(t *testing.T) {
+	testTask := TaskMap{
+		TaskHeadersKey: map[string]string{"Content-Type": testContentType},
+	}
+	testErr := errors.New(testError)
+
+	_, ok := testTask.StatusCode()
+	assert.False(t, ok)
+	assert.Empty(t, testTask.Body())
+	assert.Nil(t, testTask.Err())
+	assert.Nil(t, testTask.ResponseHeaders())
+
+	testTask.SetStatusCode(http.StatusTeapot)
+	testTask.SetBody(testBody)
+	testTask.SetErr(testErr)
+	testTask.SetResponseHeader("content-type", "text/plain")
+	testTask.SetResponseHeader("Content-Type", "application/json")
+
+	statusCode, ok := testTask.StatusCode()
+	assert.True(t, ok)
+	assert.Equal(t, statusCode, http.StatusTeapot)
+	assert.Equal(t, testTask.Body(), testBody)
+	assert.Equal(t, testTask.Err(), testErr)
+	assert.Equal(t, testTask.ResponseHeaders(), map[string]string{"Content-Type": "application/json"})
+	assert.Equal(t, testTask.Header("CONTENT-TYPE"), testContentType)
+	assert.Equal(t, testTask[TaskBodyKey], testBody)
+	assert.Equal(t, testTask[TaskStatusCodeKey], http.StatusTeapot)
+	assert.Equal(t, testTask[TaskErrorKey], testErr)
+}
+
+func TestTaskMapSetOnNilMap(t *testing.T) {
+	var testTask TaskMap
+
+	testTask.SetBody(testBody)
+
+	assert.Equal(t, testTask.Body(), testBody)
+}
+
+func TestTaskMapSetOnNilPointer(t *testing.T) {
+	var testTask *TaskMap
+
+	testTask.SetBody(testBody)
+	testTask.SetStatusCode(http.StatusOK)
+	testTask.SetResponseHeader("Content-Type", "text/plain")
+
+	assert.Empty(t, testTask.Body())
+	assert.Nil(t, testTask.ResponseHeaders())
+}
+
+func TestTaskMapStatusCodeKey(t *testing.T) {
+	assert.Equal(t, TaskStatusCodeKey, "StatusCode")
+}
+
+func TestContextMapAccessors(t *testing.T) {
+	var nilCtx *ContextMap
+	testCtx := ContextMap{
+		testCtxKey:    testCtxContent,
+		testBeforeKey: map[string]string{testCtxKey: testCtxContent},
+		testExecKey:   time.Second,
+		testAfterKey:  []string{testCtxContent},
+	}
+
+	val, ok := testCtx.String(testCtxKey)
+	assert.True(t, ok)
+	assert.Equal(t, val, testCtxContent)
+	_, ok = testCtx.String(testExecKey)
+	assert.False(t, ok)
+	assert.Equal(t, testCtx.StringMap(testBeforeKey), map[string]string{testCtxKey: testCtxContent})
+	assert.Equal(t, testCtx.Duration(testExecKey), time.Second)
+	assert.Zero(t, testCtx.Duration(testCtxKey))
+	assert.Equal(t, testCtx.Strings(testAfterKey), []string{testCtxContent})
+	assert.Nil(t, testCtx.Strings(testCtxKey))
+
+	_, ok = nilCtx.String(testCtxKey)
+	assert.False(t, ok)
+	assert.Nil(t, nilCtx.StringMap(testBeforeKey))
+	assert.Nil(t, nilCtx.Strings(testAfterKey))
+}
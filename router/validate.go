@@ -0,0 +1,440 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	rootPath = "$"
+
+	errInvalidJsonFmt      = "invalid JSON at line %d, column %d: %v"
+	errNotObjectMsg        = "must be an object"
+	errRequiredMsg         = "is required"
+	errEmptyFunctionsMsg   = "must declare at least one function"
+	errDuplicateKeyFmt     = "duplicates %s after lowercasing"
+	errRepeatedKeyMsg      = "is declared more than once"
+	errReservedFuncFmt     = "function name collides with reserved keyword '%s'"
+	errReservedAliasFmt    = "alias collides with reserved keyword '%s'"
+	errAliasTakenFmt       = "alias '%s' is already taken by %s"
+	errBlankAliasMsg       = "must not be blank"
+	errInvalidFieldFmt     = "is invalid: %v"
+	errMalformedUrlFmt     = "is not an absolute http(s) url: '%s'"
+	errUnknownMethodFmt    = "is not a known HTTP method: '%s'"
+	errNonPositiveTimeout  = "must be greater than zero"
+	errArgNameFmt          = "is not a valid argument name: '%s'"
+	errArgNameTakenFmt     = "argument '%s' is already declared"
+	errUnknownArgTypeFmt   = "is not a known argument type: '%s'"
+	errEmptyEnumMsg        = "must list at least one value"
+	errRequiredAfterOptMsg = "required argument must not follow an optional one"
+	errUnknownTransformFmt = "is not a known body transform: '%s'"
+)
+
+var httpMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+// ValidationError describes a problem with a registry document, located
+// by a path such as $['/deploy'].functions['ship'].url.
+type ValidationError struct {
+	Path string
+	Msg  string
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + " " + e.Msg
+}
+
+// ValidationErrors is the list of problems found in a registry document.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, valErr := range e {
+		msgs[i] = valErr.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+func (e *ValidationErrors) add(path string, msg string) {
+	*e = append(*e, ValidationError{Path: path, Msg: msg})
+}
+
+// ValidateRegistry checks a JSON registry document for problems that
+// would stop it loading or routing correctly: malformed JSON, missing
+// required fields, keys that are repeated or collide after lowercasing,
+// functions and aliases named after reserved keywords or each other,
+// malformed urls, methods, timeouts and body transforms, and malformed
+// argument declarations. It returns nil if the document is valid, or
+// ValidationErrors listing every problem found.
+func ValidateRegistry(contents []byte) error {
+	var errs ValidationErrors
+
+	var commands map[string]json.RawMessage
+	if err := json.Unmarshal(contents, &commands); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line, col := lineAndColumn(contents, syntaxErr.Offset)
+			errs.add(rootPath, fmt.Sprintf(errInvalidJsonFmt, line, col, err))
+		} else {
+			errs.add(rootPath, errNotObjectMsg)
+		}
+		return errs
+	}
+
+	names := sortedKeys(commands)
+	checkDuplicateKeys(rootPath, contents, names, &errs)
+	for _, name := range names {
+		validateCommand(keyPath(rootPath, name), commands[name], &errs)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func validateCommand(path string, raw json.RawMessage, errs *ValidationErrors) {
+	var fields map[string]json.RawMessage
+	if !decodeField(path, raw, &fields, errs) {
+		return
+	}
+
+	var reservedKeywords []string
+	if rawKeywords, ok := fields["reservedKeywords"]; ok {
+		decodeField(path+".reservedKeywords", rawKeywords, &reservedKeywords, errs)
+	}
+
+	var access AccessList
+	if rawAccess, ok := fields["access"]; ok {
+		decodeField(path+".access", rawAccess, &access, errs)
+	}
+
+	reserved := make(map[string]bool, len(reservedKeywords))
+	for _, keyword := range reservedKeywords {
+		reserved[strings.ToLower(keyword)] = true
+	}
+
+	funcsPath := path + ".functions"
+	rawFuncs, ok := fields["functions"]
+	if !ok {
+		errs.add(funcsPath, errRequiredMsg)
+		return
+	}
+	validateFunctions(funcsPath, rawFuncs, reserved, errs)
+}
+
+// validateFunctions checks the functions of a command, or of a group
+// nested in it. The built-in keywords are reserved beside the functions
+// unless one of them is named after the keyword.
+func validateFunctions(path string, raw json.RawMessage, reserved map[string]bool, errs *ValidationErrors) {
+	var functions map[string]json.RawMessage
+	if !decodeField(path, raw, &functions, errs) {
+		return
+	}
+	if len(functions) == 0 {
+		errs.add(path, errEmptyFunctionsMsg)
+		return
+	}
+
+	names := sortedKeys(functions)
+	checkDuplicateKeys(path, raw, names, errs)
+	taken := make(map[string]string, len(names))
+	for _, name := range names {
+		taken[strings.ToLower(name)] = fmt.Sprintf("function '%s'", strings.ToLower(name))
+	}
+	levelReserved := make(map[string]bool, len(reserved)+len(BuiltinKeywords))
+	for keyword := range reserved {
+		levelReserved[keyword] = true
+	}
+	for _, keyword := range BuiltinKeywords {
+		if taken[strings.ToLower(keyword)] == "" {
+			levelReserved[strings.ToLower(keyword)] = true
+		}
+	}
+	for _, name := range names {
+		funcPath := keyPath(path, name)
+		if reserved[strings.ToLower(name)] {
+			errs.add(funcPath, fmt.Sprintf(errReservedFuncFmt, strings.ToLower(name)))
+		}
+		validateFunction(funcPath, functions[name], reserved, errs)
+		validateAliases(funcPath, name, functions[name], levelReserved, taken, errs)
+	}
+}
+
+// validateAliases checks that a function's aliases are unique among the
+// names and aliases of the functions beside it, and are not reserved keywords.
+// The aliases that pass are added to those already taken.
+func validateAliases(path string, name string, raw json.RawMessage, reserved map[string]bool, taken map[string]string, errs *ValidationErrors) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return
+	}
+	rawAliases, ok := fields["aliases"]
+	if !ok {
+		return
+	}
+
+	var aliases []string
+	if !decodeField(path+".aliases", rawAliases, &aliases, errs) {
+		return
+	}
+
+	for i, alias := range aliases {
+		aliasPath := path + ".aliases[" + strconv.Itoa(i) + "]"
+		lowerAlias := strings.ToLower(strings.TrimSpace(alias))
+		switch {
+		case lowerAlias == "":
+			errs.add(aliasPath, errBlankAliasMsg)
+		case reserved[lowerAlias]:
+			errs.add(aliasPath, fmt.Sprintf(errReservedAliasFmt, lowerAlias))
+		case taken[lowerAlias] != "":
+			errs.add(aliasPath, fmt.Sprintf(errAliasTakenFmt, lowerAlias, taken[lowerAlias]))
+		default:
+			taken[lowerAlias] = fmt.Sprintf("an alias of function '%s'", strings.ToLower(name))
+		}
+	}
+}
+
+// validateFunction checks a function, or a group and the functions nested
+// in it. Usage is optional for groups.
+func validateFunction(path string, raw json.RawMessage, reserved map[string]bool, errs *ValidationErrors) {
+	var fields map[string]json.RawMessage
+	if !decodeField(path, raw, &fields, errs) {
+		return
+	}
+
+	rawFuncs, isGroup := fields["functions"]
+	if isGroup {
+		validateFunctions(path+".functions", rawFuncs, reserved, errs)
+	}
+
+	var usage string
+	if rawUsage, ok := fields["usage"]; !ok {
+		if !isGroup {
+			errs.add(path+".usage", errRequiredMsg)
+		}
+	} else if decodeField(path+".usage", rawUsage, &usage, errs) && strings.TrimSpace(usage) == "" {
+		errs.add(path+".usage", errRequiredMsg)
+	}
+
+	for _, name := range []string{"description", "manual", "contentType", "acknowledgement"} {
+		var val string
+		if rawVal, ok := fields[name]; ok {
+			decodeField(path+"."+name, rawVal, &val, errs)
+		}
+	}
+
+	var rawUrl string
+	if rawVal, ok := fields["url"]; ok && decodeField(path+".url", rawVal, &rawUrl, errs) {
+		if parsedUrl, err := url.Parse(rawUrl); err != nil || !parsedUrl.IsAbs() ||
+			(parsedUrl.Scheme != "http" && parsedUrl.Scheme != "https") || parsedUrl.Host == "" {
+			errs.add(path+".url", fmt.Sprintf(errMalformedUrlFmt, rawUrl))
+		}
+	}
+
+	var method string
+	if rawVal, ok := fields["method"]; ok && decodeField(path+".method", rawVal, &method, errs) {
+		if !httpMethods[strings.ToUpper(method)] {
+			errs.add(path+".method", fmt.Sprintf(errUnknownMethodFmt, method))
+		}
+	}
+
+	var async bool
+	if rawVal, ok := fields["async"]; ok {
+		decodeField(path+".async", rawVal, &async, errs)
+	}
+
+	var headers map[string]string
+	if rawVal, ok := fields["headers"]; ok {
+		decodeField(path+".headers", rawVal, &headers, errs)
+	}
+
+	var forwardHeaders []string
+	if rawVal, ok := fields["forwardHeaders"]; ok {
+		decodeField(path+".forwardHeaders", rawVal, &forwardHeaders, errs)
+	}
+
+	var query map[string]string
+	if rawVal, ok := fields["query"]; ok {
+		decodeField(path+".query", rawVal, &query, errs)
+	}
+
+	var transform string
+	if rawVal, ok := fields["bodyTransform"]; ok && decodeField(path+".bodyTransform", rawVal, &transform, errs) {
+		if transform != BodyForm && transform != BodyJSON {
+			errs.add(path+".bodyTransform", fmt.Sprintf(errUnknownTransformFmt, transform))
+		}
+	}
+
+	var access AccessList
+	if rawVal, ok := fields["access"]; ok {
+		decodeField(path+".access", rawVal, &access, errs)
+	}
+
+	var timeout Duration
+	if rawVal, ok := fields["timeout"]; ok && decodeField(path+".timeout", rawVal, &timeout, errs) && timeout <= 0 {
+		errs.add(path+".timeout", errNonPositiveTimeout)
+	}
+
+	names := make(map[string]bool)
+	var specs []ArgumentSpec
+	if rawVal, ok := fields["arguments"]; ok && decodeField(path+".arguments", rawVal, &specs, errs) {
+		validateArguments(path+".arguments", specs, names, errs)
+		optional := false
+		for i, spec := range specs {
+			isOptional := !spec.Required || spec.Default != ""
+			if optional && !isOptional {
+				errs.add(path+".arguments["+strconv.Itoa(i)+"]", errRequiredAfterOptMsg)
+			}
+			optional = optional || isOptional
+		}
+	}
+	var flags []ArgumentSpec
+	if rawVal, ok := fields["flags"]; ok && decodeField(path+".flags", rawVal, &flags, errs) {
+		validateArguments(path+".flags", flags, names, errs)
+	}
+}
+
+// validateArguments checks the declared arguments or flags of a function.
+// Names must be unique among both, and are added to those already taken.
+func validateArguments(path string, specs []ArgumentSpec, names map[string]bool, errs *ValidationErrors) {
+	for i, spec := range specs {
+		specPath := path + "[" + strconv.Itoa(i) + "]"
+		lowerName := strings.ToLower(strings.TrimSpace(spec.Name))
+		switch {
+		case lowerName == "":
+			errs.add(specPath+".name", errRequiredMsg)
+		case strings.HasPrefix(lowerName, "-") || strings.ContainsAny(lowerName, "= "):
+			errs.add(specPath+".name", fmt.Sprintf(errArgNameFmt, spec.Name))
+		case names[lowerName]:
+			errs.add(specPath+".name", fmt.Sprintf(errArgNameTakenFmt, lowerName))
+		default:
+			names[lowerName] = true
+		}
+
+		if spec.Type != "" && !argumentTypes[spec.Type] {
+			errs.add(specPath+".type", fmt.Sprintf(errUnknownArgTypeFmt, spec.Type))
+			continue
+		}
+		if spec.Type == ArgEnum && len(spec.Values) == 0 {
+			errs.add(specPath+".values", errEmptyEnumMsg)
+			continue
+		}
+		if spec.Default != "" {
+			if _, err := spec.Parse(spec.Default); err != nil {
+				errs.add(specPath+".default", fmt.Sprintf(errInvalidFieldFmt, err))
+			}
+		}
+	}
+}
+
+// decodeField decodes a raw value, recording an error at the path if it
+// does not have the expected type.
+func decodeField(path string, raw json.RawMessage, val interface{}, errs *ValidationErrors) bool {
+	if err := json.Unmarshal(raw, val); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field == "" {
+			errs.add(path, fmt.Sprintf(errInvalidFieldFmt, "expected "+typeErr.Type.String()+", got "+typeErr.Value))
+		} else {
+			errs.add(path, fmt.Sprintf(errInvalidFieldFmt, err))
+		}
+		return false
+	}
+
+	return true
+}
+
+// checkDuplicateKeys records an error for every key that is declared more
+// than once in the raw object, and for every key that is the same as an
+// earlier key once both are lowercased. The keys must be sorted.
+func checkDuplicateKeys(path string, raw []byte, keys []string, errs *ValidationErrors) {
+	for _, key := range repeatedKeys(raw) {
+		errs.add(keyPath(path, key), errRepeatedKeyMsg)
+	}
+
+	seen := make(map[string]string, len(keys))
+	for _, key := range keys {
+		lowerKey := strings.ToLower(key)
+		if firstKey, ok := seen[lowerKey]; ok {
+			errs.add(keyPath(path, key), fmt.Sprintf(errDuplicateKeyFmt, strconv.Quote(firstKey)))
+			continue
+		}
+		seen[lowerKey] = key
+	}
+}
+
+// repeatedKeys returns the keys that are declared more than once in a JSON
+// object, which decoding it into a map would silently collapse.
+func repeatedKeys(raw []byte) []string {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil
+	}
+
+	counts := make(map[string]int)
+	var repeated []string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		key, _ := tok.(string)
+		counts[key]++
+		if counts[key] == 2 {
+			repeated = append(repeated, key)
+		}
+
+		var val json.RawMessage
+		if err := dec.Decode(&val); err != nil {
+			break
+		}
+	}
+
+	return repeated
+}
+
+func keyPath(path string, key string) string {
+	return path + "['" + key + "']"
+}
+
+func sortedKeys(m map[string]json.RawMessage) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// lineAndColumn locates the byte that caused a syntax error. The offset
+// reported by the decoder is just past that byte.
+func lineAndColumn(contents []byte, offset int64) (int, int) {
+	if offset > int64(len(contents)) {
+		offset = int64(len(contents))
+	}
+	if offset > 0 {
+		offset--
+	}
+	before := contents[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := len(before) - bytes.LastIndexByte(before, '\n')
+
+	return line, col
+}
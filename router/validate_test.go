@@ -0,0 +1,263 @@
+package router
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestValidateRegistry(t *testing.T) {
+	assert.Nil(t, ValidateRegistry([]byte(testRegJson)))
+}
+
+func TestValidateRegistryDiagnostics(t *testing.T) {
+	testInvalidRegJson := `{
+           "deploy": {
+               "reservedKeywords" : ["help"],
+               "functions" : {
+                   "Help" : {
+                       "usage" : "usage"
+                   },
+                   "ship" : {
+                       "description" : "description",
+                       "url" : "not a url",
+                       "method" : "FETCH",
+                       "timeout" : "0s"
+                   },
+                   "SHIP" : {
+                       "usage" : "usage",
+                       "headers" : ["not", "a", "map"]
+                   }
+               }
+           },
+           "Deploy": {
+               "functions" : {}
+           },
+           "build": {
+               "reservedKeywords" : "help"
+           }
+        }`
+
+	err := ValidateRegistry([]byte(testInvalidRegJson))
+	var valErrs ValidationErrors
+	assert.True(t, errors.As(err, &valErrs))
+
+	paths := make(map[string]string)
+	for _, valErr := range valErrs {
+		paths[valErr.Path] = valErr.Msg
+	}
+	assert.Equal(t, paths["$['deploy']"], `duplicates "Deploy" after lowercasing`)
+	assert.Equal(t, paths["$['Deploy'].functions"], errEmptyFunctionsMsg)
+	assert.Contains(t, paths["$['build'].reservedKeywords"], "is invalid")
+	assert.Equal(t, paths["$['build'].functions"], errRequiredMsg)
+	assert.Equal(t, paths["$['deploy'].functions['Help']"], "function name collides with reserved keyword 'help'")
+	assert.Equal(t, paths["$['deploy'].functions['ship']"], `duplicates "SHIP" after lowercasing`)
+	assert.Equal(t, paths["$['deploy'].functions['ship'].usage"], errRequiredMsg)
+	assert.Equal(t, paths["$['deploy'].functions['ship'].url"], "is not an absolute http(s) url: 'not a url'")
+	assert.Equal(t, paths["$['deploy'].functions['ship'].method"], "is not a known HTTP method: 'FETCH'")
+	assert.Equal(t, paths["$['deploy'].functions['ship'].timeout"], errNonPositiveTimeout)
+	assert.Contains(t, paths["$['deploy'].functions['SHIP'].headers"], "is invalid")
+	assert.Len(t, valErrs, 11)
+}
+
+func TestValidateRegistryRepeatedKeys(t *testing.T) {
+	testRepeatedRegJson := `{
+           "/deploy": {
+               "functions" : {
+                   "ship" : {"usage" : "usage"}
+               }
+           },
+           "/deploy": {
+               "functions" : {
+                   "ship" : {"usage" : "usage"},
+                   "ship" : {"usage" : "usage"}
+               }
+           }
+        }`
+
+	err := ValidateRegistry([]byte(testRepeatedRegJson))
+	var valErrs ValidationErrors
+	assert.True(t, errors.As(err, &valErrs))
+	assert.Equal(t, valErrs, ValidationErrors{
+		{Path: "$['/deploy']", Msg: errRepeatedKeyMsg},
+		{Path: "$['/deploy'].functions['ship']", Msg: errRepeatedKeyMsg},
+	})
+}
+
+func TestValidateRegistrySyntaxError(t *testing.T) {
+	err := ValidateRegistry([]byte("{\n  \"command\": {\n    malformed\n}"))
+	var valErrs ValidationErrors
+	assert.True(t, errors.As(err, &valErrs))
+	assert.Len(t, valErrs, 1)
+	assert.Equal(t, valErrs[0].Path, rootPath)
+	assert.Contains(t, valErrs[0].Msg, "line 3, column 5")
+}
+
+func TestValidateRegistryNotObject(t *testing.T) {
+	err := ValidateRegistry([]byte(`["command"]`))
+	assert.Equal(t, err.Error(), "$ "+errNotObjectMsg)
+}
+
+func TestLoadRegistryFromInvalidContents(t *testing.T) {
+	testContents := []byte(`{"command": {"functions": {"function": {}}}}`)
+	var valErrs ValidationErrors
+	assert.True(t, errors.As(ValidateRegistry(testContents), &valErrs))
+	assert.Equal(t, valErrs[0].Path, "$['command'].functions['function'].usage")
+
+	cmdReg, err := NewCommandRegistryFromContents(testContents)
+	assert.Nil(t, err)
+	assert.Equal(t, cmdReg.FunctionNames("command"), []string{"function"})
+}
+
+func TestValidateRegistryAliases(t *testing.T) {
+	err := ValidateRegistry([]byte(`{
+           "deploy": {
+               "reservedKeywords" : ["admin"],
+               "functions" : {
+                   "rollback" : {
+                       "usage" : "usage",
+                       "aliases" : ["undo", "ship", "help"]
+                   },
+                   "ship" : {
+                       "usage" : "usage",
+                       "aliases" : ["release", "Undo", "list", "admin", " "]
+                   },
+                   "list" : {
+                       "usage" : "usage",
+                       "aliases" : "release"
+                   }
+               }
+           }
+        }`))
+	var valErrs ValidationErrors
+	assert.True(t, errors.As(err, &valErrs))
+
+	paths := make(map[string]string)
+	for _, valErr := range valErrs {
+		paths[valErr.Path] = valErr.Msg
+	}
+	_, listReserved := paths["$['deploy'].functions['list']"]
+	assert.False(t, listReserved)
+	assert.Contains(t, paths["$['deploy'].functions['list'].aliases"], "is invalid")
+	assert.Equal(t, paths["$['deploy'].functions['rollback'].aliases[1]"], "alias 'ship' is already taken by function 'ship'")
+	assert.Equal(t, paths["$['deploy'].functions['rollback'].aliases[2]"], "alias collides with reserved keyword 'help'")
+	assert.Equal(t, paths["$['deploy'].functions['ship'].aliases[1]"], "alias 'undo' is already taken by an alias of function 'rollback'")
+	assert.Equal(t, paths["$['deploy'].functions['ship'].aliases[2]"], "alias 'list' is already taken by function 'list'")
+	assert.Equal(t, paths["$['deploy'].functions['ship'].aliases[3]"], "alias collides with reserved keyword 'admin'")
+	assert.Equal(t, paths["$['deploy'].functions['ship'].aliases[4]"], errBlankAliasMsg)
+	assert.Len(t, valErrs, 7)
+}
+
+func TestValidateRegistryNestedFunctions(t *testing.T) {
+	assert.Nil(t, ValidateRegistry([]byte(testNestedRegJson)))
+
+	err := ValidateRegistry([]byte(`{
+           "/ops": {
+               "functions" : {
+                   "db" : {
+                       "functions" : {
+                           "backup" : {
+                               "url" : "not a url"
+                           },
+                           "help" : {
+                               "usage" : "usage"
+                           },
+                           "empty" : {
+                               "functions" : {}
+                           }
+                       }
+                   }
+               }
+           }
+        }`))
+	var valErrs ValidationErrors
+	assert.True(t, errors.As(err, &valErrs))
+
+	paths := make(map[string]string)
+	for _, valErr := range valErrs {
+		paths[valErr.Path] = valErr.Msg
+	}
+	assert.Equal(t, paths["$['/ops'].functions['db'].functions['backup'].usage"], errRequiredMsg)
+	assert.Equal(t, paths["$['/ops'].functions['db'].functions['backup'].url"], "is not an absolute http(s) url: 'not a url'")
+	_, helpReserved := paths["$['/ops'].functions['db'].functions['help']"]
+	assert.False(t, helpReserved)
+	assert.Equal(t, paths["$['/ops'].functions['db'].functions['empty'].functions"], errEmptyFunctionsMsg)
+	assert.Len(t, valErrs, 3)
+}
+
+func TestValidateRegistryArguments(t *testing.T) {
+	err := ValidateRegistry([]byte(`{
+           "/deploy": {
+               "functions" : {
+                   "ship" : {
+                       "usage" : "/deploy ship <service> [env]",
+                       "arguments" : [
+                           {"name" : "service", "required" : true},
+                           {"name" : "env", "type" : "enum"},
+                           {"name" : "version", "required" : true}
+                       ],
+                       "flags" : [
+                           {"name" : "Service"},
+                           {"name" : "--wait", "type" : "duration"},
+                           {"name" : "retries", "type" : "int", "default" : "many"},
+                           {"name" : "owner", "type" : "person"},
+                           {"type" : "string"}
+                       ]
+                   },
+                   "rollback" : {
+                       "usage" : "/deploy rollback",
+                       "arguments" : {"name" : "service"}
+                   }
+               }
+           }
+        }`))
+	var valErrs ValidationErrors
+	assert.True(t, errors.As(err, &valErrs))
+
+	paths := make(map[string]string)
+	for _, valErr := range valErrs {
+		paths[valErr.Path] = valErr.Msg
+	}
+	shipPath := "$['/deploy'].functions['ship']"
+	assert.Equal(t, paths[shipPath+".arguments[1].values"], errEmptyEnumMsg)
+	assert.Equal(t, paths[shipPath+".arguments[2]"], errRequiredAfterOptMsg)
+	assert.Equal(t, paths[shipPath+".flags[0].name"], "argument 'service' is already declared")
+	assert.Equal(t, paths[shipPath+".flags[1].name"], "is not a valid argument name: '--wait'")
+	assert.Equal(t, paths[shipPath+".flags[2].default"], "is invalid: 'retries' must be a whole number, not 'many'.")
+	assert.Equal(t, paths[shipPath+".flags[3].type"], "is not a known argument type: 'person'")
+	assert.Equal(t, paths[shipPath+".flags[4].name"], errRequiredMsg)
+	assert.Contains(t, paths["$['/deploy'].functions['rollback'].arguments"], "is invalid")
+	assert.Len(t, valErrs, 8)
+}
+
+func TestValidateRegistryRequestShaping(t *testing.T) {
+	err := ValidateRegistry([]byte(`{
+           "/deploy": {
+               "functions" : {
+                   "ship" : {
+                       "usage" : "/deploy ship <service>",
+                       "forwardHeaders" : "X-Request-Id",
+                       "query" : {"user" : 1},
+                       "bodyTransform" : "xml"
+                   },
+                   "status" : {
+                       "usage" : "/deploy status",
+                       "forwardHeaders" : ["X-Request-Id"],
+                       "query" : {"user" : "{user_id}"},
+                       "bodyTransform" : "json"
+                   }
+               }
+           }
+        }`))
+	var valErrs ValidationErrors
+	assert.True(t, errors.As(err, &valErrs))
+
+	paths := make(map[string]string)
+	for _, valErr := range valErrs {
+		paths[valErr.Path] = valErr.Msg
+	}
+	assert.Contains(t, paths["$['/deploy'].functions['ship'].forwardHeaders"], "is invalid")
+	assert.Contains(t, paths["$['/deploy'].functions['ship'].query"], "is invalid")
+	assert.Equal(t, paths["$['/deploy'].functions['ship'].bodyTransform"], "is not a known body transform: 'xml'")
+	assert.Len(t, valErrs, 3)
+}
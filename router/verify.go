@@ -0,0 +1,279 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	signatureExt = ".sig"
+
+	errUnsignedRegFmt     = "Registry is unsigned, but a public key is configured. Error: %v"
+	errNoSignatureMsg     = "source has no detached signature"
+	errBadSignatureMsg    = "Registry signature is invalid."
+	errMalformedSigMsg    = "Registry signature is malformed."
+	errBadPublicKeyMsg    = "Registry public key is not an ed25519 public key."
+	errDigestMismatchFmt  = "Registry SHA-256 digest '%s' does not match the pinned digest '%s'."
+	errMalformedDigestFmt = "Pinned registry digest is not a hex SHA-256 digest: '%s'"
+)
+
+// VerifyOptions configures the integrity checks made on registry documents
+// before they are loaded. The zero value makes no checks.
+type VerifyOptions struct {
+	// PublicKey, when set, requires every document to have a valid ed25519
+	// detached signature. The signature is read from beside the document,
+	// at its location with .sig appended, as 64 raw bytes or in base64.
+	// Objects pinned to a version are signed per version, with the version
+	// id and .sig appended to their key, as in registry.json.<id>.sig.
+	PublicKey ed25519.PublicKey
+	// SHA256, when set, pins the hex SHA-256 digest documents must have.
+	SHA256 string
+}
+
+// VerificationError is returned when a registry document fails an
+// integrity check.
+type VerificationError struct {
+	// Source describes where the document was loaded from.
+	Source string
+	// Msg says which check failed.
+	Msg string
+}
+
+func (e VerificationError) Error() string {
+	return e.Msg + " Source: " + e.Source
+}
+
+// SignatureFetcher is implemented by sources that can fetch the detached
+// signature kept alongside their document.
+type SignatureFetcher interface {
+	FetchSignature(ctx context.Context) ([]byte, error)
+}
+
+// VerifiedSource checks the documents fetched from its Source with its
+// Options before they are loaded.
+type VerifiedSource struct {
+	Source  RegistrySource
+	Options VerifyOptions
+}
+
+// NewVerifiedSource is a factory method for creating a source that checks
+// the documents fetched from src with the given options.
+func NewVerifiedSource(src RegistrySource, opts VerifyOptions) VerifiedSource {
+	return VerifiedSource{Source: src, Options: opts}
+}
+
+// Fetch fetches the document from the source, and returns it only if it
+// passes the checks.
+func (s VerifiedSource) Fetch(ctx context.Context) ([]byte, RegistryFormat, error) {
+	contents, format, err := s.Source.Fetch(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := verifyRegistry(ctx, contents, s.Source, s.Options); err != nil {
+		return nil, "", err
+	}
+
+	return contents, format, nil
+}
+
+func (s VerifiedSource) String() string {
+	return s.Source.String()
+}
+
+// NewCommandRegistryFromSourceWithVerification behaves like
+// NewCommandRegistryFromSource, checking the document with the given
+// options.
+func NewCommandRegistryFromSourceWithVerification(src RegistrySource, opts VerifyOptions) (*commandRegistry, error) {
+	return NewCommandRegistryFromSource(NewVerifiedSource(src, opts))
+}
+
+// NewCommandRegistryWithVerification behaves like
+// NewCommandRegistryWithFetcher, checking the document of every location
+// it tries with the given options.
+func NewCommandRegistryWithVerification(location string, fetcher ObjectFetcher, opts VerifyOptions) (*commandRegistry, error) {
+	chain := DefaultSourceChainWithFetcher(location, fetcher)
+	verified := make(SourceChain, len(chain))
+	for i, src := range chain {
+		verified[i] = NewVerifiedSource(src, opts)
+	}
+
+	return verified.Load()
+}
+
+// NewCommandRegistryFromFileWithVerification behaves like
+// NewCommandRegistryFromFile, checking the file with the given options.
+func NewCommandRegistryFromFileWithVerification(fileLoc string, opts VerifyOptions) (*commandRegistry, error) {
+	return NewCommandRegistryFromSourceWithVerification(NewFileSource(fileLoc), opts)
+}
+
+// NewCommandRegistryFromUrlWithVerification behaves like
+// NewCommandRegistryFromUrl, checking the download with the given options.
+func NewCommandRegistryFromUrlWithVerification(url string, opts VerifyOptions) (*commandRegistry, error) {
+	return NewCommandRegistryFromSourceWithVerification(NewURLSource(url), opts)
+}
+
+// verifyRegistry checks the pinned digest first, as it needs no further
+// fetches, and then the detached signature.
+func verifyRegistry(ctx context.Context, contents []byte, src RegistrySource, opts VerifyOptions) error {
+	if opts.SHA256 != "" {
+		pinned, err := hex.DecodeString(strings.TrimSpace(opts.SHA256))
+		if err != nil || len(pinned) != sha256.Size {
+			return VerificationError{Source: src.String(), Msg: fmt.Sprintf(errMalformedDigestFmt, opts.SHA256)}
+		}
+		digest := sha256.Sum256(contents)
+		if !bytes.Equal(digest[:], pinned) {
+			return VerificationError{
+				Source: src.String(),
+				Msg:    fmt.Sprintf(errDigestMismatchFmt, hex.EncodeToString(digest[:]), strings.ToLower(opts.SHA256)),
+			}
+		}
+	}
+
+	if opts.PublicKey == nil {
+		return nil
+	}
+	if len(opts.PublicKey) != ed25519.PublicKeySize {
+		return VerificationError{Source: src.String(), Msg: errBadPublicKeyMsg}
+	}
+
+	sigFetcher, ok := src.(SignatureFetcher)
+	if !ok {
+		return VerificationError{Source: src.String(), Msg: fmt.Sprintf(errUnsignedRegFmt, errNoSignatureMsg)}
+	}
+	rawSig, err := sigFetcher.FetchSignature(ctx)
+	if err != nil {
+		return VerificationError{Source: src.String(), Msg: fmt.Sprintf(errUnsignedRegFmt, err)}
+	}
+	sig, ok := decodeSignature(rawSig)
+	if !ok {
+		return VerificationError{Source: src.String(), Msg: errMalformedSigMsg}
+	}
+	if !ed25519.Verify(opts.PublicKey, contents, sig) {
+		return VerificationError{Source: src.String(), Msg: errBadSignatureMsg}
+	}
+
+	return nil
+}
+
+// decodeSignature accepts a signature as raw bytes or in base64.
+func decodeSignature(rawSig []byte) ([]byte, bool) {
+	if len(rawSig) == ed25519.SignatureSize {
+		return rawSig, true
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(rawSig)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return nil, false
+	}
+
+	return sig, true
+}
+
+// signatureUrl appends the signature extension to the path of a url,
+// keeping its query.
+func signatureUrl(rawUrl string) (*url.URL, error) {
+	parsedUrl, err := url.Parse(rawUrl)
+	if err != nil {
+		return nil, err
+	}
+	parsedUrl.Path += signatureExt
+	parsedUrl.RawPath = ""
+
+	return parsedUrl, nil
+}
+
+// FetchSignature reads the signature file beside the registry file.
+func (s FileSource) FetchSignature(ctx context.Context) ([]byte, error) {
+	if s.Path == "" {
+		return nil, errors.New(errEmptyFileLocMsg)
+	}
+
+	return ioutil.ReadFile(s.Path + signatureExt)
+}
+
+// FetchSignature downloads the signature beside the registry url.
+func (s URLSource) FetchSignature(ctx context.Context) ([]byte, error) {
+	sigUrl, err := signatureUrl(s.Url)
+	if err != nil {
+		return nil, err
+	}
+
+	client := s.Client
+	if client == nil {
+		client = NewRegistryHTTPClient(DefaultRegistryHTTPOptions())
+	}
+
+	dl, err := client.fetch(ctx, sigUrl.String(), "")
+	if err != nil {
+		return nil, err
+	}
+
+	return dl.contents, nil
+}
+
+// FetchSignature gets the signature object beside the registry object. A
+// registry object pinned to a version has a signature object for that
+// version.
+func (s ObjectSource) FetchSignature(ctx context.Context) ([]byte, error) {
+	if s.Fetcher == nil {
+		return nil, errors.New(errNoFetcherMsg)
+	}
+
+	loc, err := ParseObjectLocation(s.Location)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := s.Fetcher.FetchObject(ctx, signatureLocation(loc))
+	if err != nil {
+		return nil, err
+	}
+
+	return obj.Contents, nil
+}
+
+// signatureLocation is the location of the latest signature of an object,
+// or of the signature made for the version the location is pinned to.
+func signatureLocation(loc ObjectLocation) ObjectLocation {
+	key := loc.Key
+	if loc.VersionId != "" {
+		key += "." + loc.VersionId
+	}
+
+	return ObjectLocation{Bucket: loc.Bucket, Key: key + signatureExt}
+}
+
+// FetchSignature fetches the signature beside the location in the
+// environment variable.
+func (s EnvSource) FetchSignature(ctx context.Context) ([]byte, error) {
+	location := os.Getenv(s.Name)
+	if location == "" {
+		return nil, fmt.Errorf(errEnvUnsetFmt, s.Name)
+	}
+
+	sigFetcher, ok := NewLocationSourceWithFetcher(location, s.Fetcher).(SignatureFetcher)
+	if !ok {
+		return nil, errors.New(errNoSignatureMsg)
+	}
+
+	return sigFetcher.FetchSignature(ctx)
+}
+
+// FetchSignature returns the signature given with the document.
+func (s MemorySource) FetchSignature(ctx context.Context) ([]byte, error) {
+	if s.Signature == nil {
+		return nil, errors.New(errNoSignatureMsg)
+	}
+
+	return s.Signature, nil
+}
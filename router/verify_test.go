@@ -0,0 +1,212 @@
+package router
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func newTestKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	assert.Nil(t, err)
+
+	return publicKey, privateKey
+}
+
+func assertVerificationError(t *testing.T, err error, msg string) {
+	var verErr VerificationError
+	assert.True(t, errors.As(err, &verErr))
+	assert.Contains(t, verErr.Msg, msg)
+}
+
+func TestVerifySignedMemorySource(t *testing.T) {
+	publicKey, privateKey := newTestKey(t)
+	_, otherKey := newTestKey(t)
+	opts := VerifyOptions{PublicKey: publicKey}
+	contents := []byte(testRegYaml)
+
+	src := NewMemorySource(contents, FormatYAML)
+	src.Signature = ed25519.Sign(privateKey, contents)
+	cmdReg, err := NewCommandRegistryFromSourceWithVerification(src, opts)
+	assertTestShipRegistry(t, cmdReg, err)
+
+	src.Signature = []byte(base64.StdEncoding.EncodeToString(src.Signature) + "\n")
+	cmdReg, err = NewCommandRegistryFromSourceWithVerification(src, opts)
+	assertTestShipRegistry(t, cmdReg, err)
+
+	src.Signature = ed25519.Sign(otherKey, contents)
+	cmdReg, err = NewCommandRegistryFromSourceWithVerification(src, opts)
+	assert.Nil(t, cmdReg)
+	assertVerificationError(t, err, errBadSignatureMsg)
+
+	src.Signature = []byte("not a signature")
+	_, err = NewCommandRegistryFromSourceWithVerification(src, opts)
+	assertVerificationError(t, err, errMalformedSigMsg)
+
+	src.Signature = nil
+	_, err = NewCommandRegistryFromSourceWithVerification(src, opts)
+	assertVerificationError(t, err, "Registry is unsigned")
+
+	_, err = NewCommandRegistryFromSourceWithVerification(failingSignatureSource{src}, opts)
+	assertVerificationError(t, err, "Registry is unsigned")
+}
+
+type failingSignatureSource struct {
+	src MemorySource
+}
+
+func (s failingSignatureSource) Fetch(ctx context.Context) ([]byte, RegistryFormat, error) {
+	return s.src.Fetch(ctx)
+}
+
+func (s failingSignatureSource) String() string {
+	return "unsigned"
+}
+
+func TestVerifyPinnedDigest(t *testing.T) {
+	contents := []byte(testRegJson)
+	digest := sha256.Sum256(contents)
+	src := NewMemorySource(contents, FormatJSON)
+
+	cmdReg, err := NewCommandRegistryFromSourceWithVerification(src, VerifyOptions{SHA256: hex.EncodeToString(digest[:])})
+	assert.Nil(t, err)
+	assert.NotNil(t, cmdReg)
+
+	otherDigest := sha256.Sum256([]byte(testRegYaml))
+	cmdReg, err = NewCommandRegistryFromSourceWithVerification(src, VerifyOptions{SHA256: hex.EncodeToString(otherDigest[:])})
+	assert.Nil(t, cmdReg)
+	assertVerificationError(t, err, "does not match the pinned digest")
+
+	_, err = NewCommandRegistryFromSourceWithVerification(src, VerifyOptions{SHA256: "abc"})
+	assertVerificationError(t, err, "is not a hex SHA-256 digest")
+}
+
+func TestVerifySignedFile(t *testing.T) {
+	publicKey, privateKey := newTestKey(t)
+	regPath := filepath.Join(t.TempDir(), "registry.yaml")
+	assert.Nil(t, ioutil.WriteFile(regPath, []byte(testRegYaml), 0644))
+
+	src := NewVerifiedSource(NewFileSource(regPath), VerifyOptions{PublicKey: publicKey})
+
+	cmdReg, err := NewCommandRegistryFromSource(src)
+	assert.Nil(t, cmdReg)
+	assertVerificationError(t, err, "Registry is unsigned")
+
+	assert.Nil(t, ioutil.WriteFile(regPath+".sig", ed25519.Sign(privateKey, []byte(testRegYaml)), 0644))
+	cmdReg, err = NewCommandRegistryFromSource(src)
+	assertTestShipRegistry(t, cmdReg, err)
+
+	cmdReg, err = NewCommandRegistryFromFile(regPath)
+	assertTestShipRegistry(t, cmdReg, err)
+
+	opts := VerifyOptions{PublicKey: publicKey}
+	cmdReg, err = NewCommandRegistryFromFileWithVerification(regPath, opts)
+	assertTestShipRegistry(t, cmdReg, err)
+	cmdReg, err = NewCommandRegistryWithVerification(regPath, nil, opts)
+	assertTestShipRegistry(t, cmdReg, err)
+
+	assert.Nil(t, ioutil.WriteFile(regPath, []byte(testRegYaml+"\n# tampered\n"), 0644))
+	cmdReg, err = NewCommandRegistryFromFileWithVerification(regPath, opts)
+	assert.Nil(t, cmdReg)
+	assertVerificationError(t, err, errBadSignatureMsg)
+
+	t.Setenv(regFileEnvVar, "")
+	t.Setenv(regUrlEnvVar, "")
+	cmdReg, err = NewCommandRegistryWithVerification(regPath, nil, opts)
+	assert.Nil(t, cmdReg)
+	assertVerificationError(t, err, errBadSignatureMsg)
+}
+
+func TestVerifySignedUrl(t *testing.T) {
+	publicKey, privateKey := newTestKey(t)
+	tampered := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/registry.yaml":
+			if tampered {
+				w.Write([]byte(testRegYaml + "\n# tampered\n"))
+				return
+			}
+			w.Write([]byte(testRegYaml))
+		case "/registry.yaml.sig":
+			w.Write([]byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(testRegYaml)))))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	src := NewURLSource(server.URL + "/registry.yaml")
+	opts := VerifyOptions{PublicKey: publicKey}
+
+	cmdReg, err := NewCommandRegistryFromSourceWithVerification(src, opts)
+	assertTestShipRegistry(t, cmdReg, err)
+
+	cmdReg, err = NewCommandRegistryFromUrlWithVerification(server.URL+"/registry.yaml", opts)
+	assertTestShipRegistry(t, cmdReg, err)
+
+	tampered = true
+	cmdReg, err = NewCommandRegistryFromSourceWithVerification(src, opts)
+	assert.Nil(t, cmdReg)
+	assertVerificationError(t, err, errBadSignatureMsg)
+
+	cmdReg, err = NewCommandRegistryFromUrlWithVerification(server.URL+"/registry.yaml", opts)
+	assert.Nil(t, cmdReg)
+	assertVerificationError(t, err, errBadSignatureMsg)
+}
+
+type ctxRecordingFetcher struct {
+	ObjectFetcher
+	ctxs []context.Context
+}
+
+func (f *ctxRecordingFetcher) FetchObject(ctx context.Context, loc ObjectLocation) (*Object, error) {
+	f.ctxs = append(f.ctxs, ctx)
+	return f.ObjectFetcher.FetchObject(ctx, loc)
+}
+
+func TestVerifySignedObjectVersion(t *testing.T) {
+	publicKey, privateKey := newTestKey(t)
+	memFetcher := newMemoryObjectFetcher()
+	firstVersion := memFetcher.PutObject(testBucket, testKey, []byte(testRegYaml), "")
+	memFetcher.PutObject(testBucket, testKey+"."+firstVersion+".sig", ed25519.Sign(privateKey, []byte(testRegYaml)), "")
+	memFetcher.PutObject(testBucket, testKey, []byte(testManagerRegJson), "application/json")
+	memFetcher.PutObject(testBucket, testKey+".sig", ed25519.Sign(privateKey, []byte(testManagerRegJson)), "")
+	fetcher := &ctxRecordingFetcher{ObjectFetcher: memFetcher}
+	opts := VerifyOptions{PublicKey: publicKey}
+
+	type ctxKey string
+	ctx := context.WithValue(context.Background(), ctxKey("request"), "pinned")
+	src := NewVerifiedSource(NewObjectSource("s3://registries/slack/registry.yaml?versionId="+firstVersion, fetcher), opts)
+	cmdReg, err := NewCommandRegistryFromSourceContext(ctx, src)
+	assertTestShipRegistry(t, cmdReg, err)
+	assert.Len(t, fetcher.ctxs, 2)
+	for _, fetchCtx := range fetcher.ctxs {
+		assert.Equal(t, fetchCtx.Value(ctxKey("request")), "pinned")
+	}
+
+	cmdReg, err = NewCommandRegistryFromSourceWithVerification(NewObjectSource("s3://registries/slack/registry.yaml", fetcher), opts)
+	assert.Nil(t, err)
+	assert.Equal(t, cmdReg.FunctionNames(testCommand), []string{"function1"})
+}
+
+func TestVerifyRejectsMalformedPublicKey(t *testing.T) {
+	src := NewMemorySource([]byte(testRegJson), FormatJSON)
+	_, err := NewCommandRegistryFromSourceWithVerification(src, VerifyOptions{PublicKey: ed25519.PublicKey("short")})
+	assertVerificationError(t, err, errBadPublicKeyMsg)
+}
+
+func TestSignatureUrl(t *testing.T) {
+	sigUrl, err := signatureUrl("https://example.com/registry.json?token=abc")
+	assert.Nil(t, err)
+	assert.Equal(t, sigUrl.String(), "https://example.com/registry.json.sig?token=abc")
+}